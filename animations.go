@@ -0,0 +1,364 @@
+package nanoleaf
+
+import (
+	"image/color"
+	"math"
+)
+
+// Palette based animTypes supported by the firmware
+const (
+	AnimTypeRandom    = "random"
+	AnimTypeFlow      = "flow"
+	AnimTypeWheel     = "wheel"
+	AnimTypeFade      = "fade"
+	AnimTypeHighlight = "highlight"
+	AnimTypeExplode   = "explode"
+)
+
+// Directions used by flow, wheel and explode effects
+const (
+	DirectionLeft     = "left"
+	DirectionRight    = "right"
+	DirectionUp       = "up"
+	DirectionDown     = "down"
+	DirectionOutwards = "outwards"
+	DirectionInwards  = "inwards"
+)
+
+// PaletteColor describes a single palette entry
+type PaletteColor struct {
+	Hue         int     `json:"hue"`
+	Saturation  int     `json:"saturation"`
+	Brightness  int     `json:"brightness"`
+	Probability float64 `json:"probability,omitempty"`
+}
+
+// Palette list of colors used by palette based effects
+type Palette []PaletteColor
+
+// TimeRange describes a min / max range in tenths of a second
+type TimeRange struct {
+	Max int `json:"maxValue"`
+	Min int `json:"minValue"`
+}
+
+// BrightnessRange describes a min / max brightness
+type BrightnessRange struct {
+	Max int `json:"maxValue"`
+	Min int `json:"minValue"`
+}
+
+// PaletteEffect describes an effect built from a palette instead of frames
+type PaletteEffect struct {
+	Name            string           `json:"animName"`
+	Type            string           `json:"animType"`
+	ColorType       string           `json:"colorType"`
+	Palette         Palette          `json:"palette"`
+	TransTime       TimeRange        `json:"transTime"`
+	DelayTime       TimeRange        `json:"delayTime"`
+	BrightnessRange *BrightnessRange `json:"brightnessRange,omitempty"`
+	Direction       string           `json:"direction,omitempty"`
+	WindowSize      int              `json:"windowSize,omitempty"`
+	FlowFactor      float64          `json:"flowFactor,omitempty"`
+	ExplodeFactor   float64          `json:"explodeFactor,omitempty"`
+	Loop            bool             `json:"loop"`
+}
+
+// NewPalette returns a palette of the given colors with equal probabilities
+func NewPalette(colors ...color.Color) (Palette, error) {
+	weights := make([]float64, len(colors))
+
+	for i := range weights {
+		weights[i] = 1
+	}
+
+	return NewWeightedPalette(colors, weights)
+}
+
+// NewWeightedPalette returns a palette of the given colors whose probabilities are proportional to weights
+func NewWeightedPalette(colors []color.Color, weights []float64) (Palette, error) {
+	if len(colors) == 0 || len(colors) != len(weights) {
+		return nil, ErrInvalidPalette
+	}
+
+	total := 0.0
+	for _, weight := range weights {
+		if weight < 0 {
+			return nil, ErrInvalidPalette
+		}
+
+		total += weight
+	}
+
+	if total == 0 {
+		return nil, ErrInvalidPalette
+	}
+
+	palette := make(Palette, len(colors))
+	for i, c := range colors {
+		hue, sat, bri := rgbToHSB(c)
+		palette[i] = PaletteColor{
+			Hue:         hue,
+			Saturation:  sat,
+			Brightness:  bri,
+			Probability: weights[i] / total * 100,
+		}
+	}
+
+	return palette, nil
+}
+
+// Validate checks if every palette entry is within the ranges accepted by the api
+func (p Palette) Validate() error {
+	if len(p) == 0 {
+		return ErrInvalidPalette
+	}
+
+	for _, c := range p {
+		if c.Hue < 0 || c.Hue > 359 || c.Saturation < 0 || c.Saturation > 100 ||
+			c.Brightness < 0 || c.Brightness > 100 || c.Probability < 0 || c.Probability > 100 {
+			return ErrInvalidPalette
+		}
+	}
+
+	return nil
+}
+
+// NewRandomEffect returns a random effect
+func NewRandomEffect(name string, palette Palette, transTime, delayTime TimeRange, brightness *BrightnessRange) PaletteEffect {
+	return PaletteEffect{
+		Name:            name,
+		Type:            AnimTypeRandom,
+		ColorType:       "HSB",
+		Palette:         palette,
+		TransTime:       transTime,
+		DelayTime:       delayTime,
+		BrightnessRange: brightness,
+		Loop:            true,
+	}
+}
+
+// NewFlowEffect returns a flow effect
+func NewFlowEffect(name string, palette Palette, transTime, delayTime TimeRange, direction string, flowFactor float64, loop bool) PaletteEffect {
+	return PaletteEffect{
+		Name:       name,
+		Type:       AnimTypeFlow,
+		ColorType:  "HSB",
+		Palette:    palette,
+		TransTime:  transTime,
+		DelayTime:  delayTime,
+		Direction:  direction,
+		FlowFactor: flowFactor,
+		Loop:       loop,
+	}
+}
+
+// NewWheelEffect returns a wheel effect
+func NewWheelEffect(name string, palette Palette, transTime, delayTime TimeRange, direction string, windowSize int, loop bool) PaletteEffect {
+	return PaletteEffect{
+		Name:       name,
+		Type:       AnimTypeWheel,
+		ColorType:  "HSB",
+		Palette:    palette,
+		TransTime:  transTime,
+		DelayTime:  delayTime,
+		Direction:  direction,
+		WindowSize: windowSize,
+		Loop:       loop,
+	}
+}
+
+// NewFadeEffect returns a fade effect
+func NewFadeEffect(name string, palette Palette, transTime, delayTime TimeRange, loop bool) PaletteEffect {
+	return PaletteEffect{
+		Name:      name,
+		Type:      AnimTypeFade,
+		ColorType: "HSB",
+		Palette:   palette,
+		TransTime: transTime,
+		DelayTime: delayTime,
+		Loop:      loop,
+	}
+}
+
+// NewHighlightEffect returns a highlight effect. The palette probabilities decide how often a color is highlighted,
+// brightness is required by the firmware
+func NewHighlightEffect(name string, palette Palette, transTime, delayTime TimeRange, brightness *BrightnessRange) PaletteEffect {
+	return PaletteEffect{
+		Name:            name,
+		Type:            AnimTypeHighlight,
+		ColorType:       "HSB",
+		Palette:         palette,
+		TransTime:       transTime,
+		DelayTime:       delayTime,
+		BrightnessRange: brightness,
+		Loop:            true,
+	}
+}
+
+// NewExplodeEffect returns an explode effect
+func NewExplodeEffect(name string, palette Palette, transTime, delayTime TimeRange, direction string, explodeFactor float64, loop bool) PaletteEffect {
+	return PaletteEffect{
+		Name:          name,
+		Type:          AnimTypeExplode,
+		ColorType:     "HSB",
+		Palette:       palette,
+		TransTime:     transTime,
+		DelayTime:     delayTime,
+		Direction:     direction,
+		ExplodeFactor: explodeFactor,
+		Loop:          loop,
+	}
+}
+
+// Validate checks if the effect carries every parameter required by its animType
+func (p PaletteEffect) Validate() error {
+	if err := p.Palette.Validate(); err != nil {
+		return err
+	}
+
+	if !validTimeRange(p.TransTime) || !validTimeRange(p.DelayTime) {
+		return ErrInvalidTiming
+	}
+
+	if p.BrightnessRange != nil {
+		b := p.BrightnessRange
+		if b.Min < 0 || b.Max > 100 || b.Min > b.Max {
+			return ErrInvalidBrightnessRange
+		}
+	}
+
+	switch p.Type {
+	case AnimTypeRandom, AnimTypeFade:
+		return nil
+	case AnimTypeHighlight:
+		if p.BrightnessRange == nil {
+			return ErrInvalidBrightnessRange
+		}
+
+		return nil
+	case AnimTypeFlow:
+		if !validDirection(p.Direction, DirectionLeft, DirectionRight, DirectionUp, DirectionDown, DirectionOutwards, DirectionInwards) {
+			return ErrInvalidDirection
+		}
+
+		if p.FlowFactor <= 0 {
+			return ErrInvalidFlowFactor
+		}
+
+		return nil
+	case AnimTypeWheel:
+		if !validDirection(p.Direction, DirectionLeft, DirectionRight, DirectionUp, DirectionDown) {
+			return ErrInvalidDirection
+		}
+
+		if p.WindowSize <= 0 {
+			return ErrInvalidWindowSize
+		}
+
+		return nil
+	case AnimTypeExplode:
+		if !validDirection(p.Direction, DirectionOutwards, DirectionInwards) {
+			return ErrInvalidDirection
+		}
+
+		if p.ExplodeFactor <= 0 {
+			return ErrInvalidExplodeFactor
+		}
+
+		return nil
+	}
+
+	return ErrInvalidAnimType
+}
+
+// payload returns the write payload of the effect for the given command
+func (p PaletteEffect) payload(command string) jsonPayload {
+	write := struct {
+		Command string `json:"command"`
+		PaletteEffect
+	}{command, p}
+
+	return jsonPayload{"write": write}
+}
+
+// validTimeRange checks if given range is usable as transTime or delayTime
+func validTimeRange(r TimeRange) bool {
+	return r.Min >= 0 && r.Max >= r.Min
+}
+
+// validDirection checks if given direction is one of allowed
+func validDirection(direction string, allowed ...string) bool {
+	for _, a := range allowed {
+		if direction == a {
+			return true
+		}
+	}
+
+	return false
+}
+
+// rgbToHSB converts given color into nanoleafs hue (0-359), saturation (0-100) and brightness (0-100)
+func rgbToHSB(c color.Color) (int, int, int) {
+	r, g, b, _ := c.RGBA()
+	rf, gf, bf := float64(r)/0xffff, float64(g)/0xffff, float64(b)/0xffff
+
+	max := math.Max(rf, math.Max(gf, bf))
+	min := math.Min(rf, math.Min(gf, bf))
+	delta := max - min
+
+	hue := 0.0
+	switch {
+	case delta == 0:
+		hue = 0
+	case max == rf:
+		hue = 60 * math.Mod((gf-bf)/delta, 6)
+	case max == gf:
+		hue = 60 * ((bf-rf)/delta + 2)
+	default:
+		hue = 60 * ((rf-gf)/delta + 4)
+	}
+
+	if hue < 0 {
+		hue += 360
+	}
+
+	sat := 0.0
+	if max > 0 {
+		sat = delta / max
+	}
+
+	return int(math.Round(hue)) % 360, int(math.Round(sat * 100)), int(math.Round(max * 100))
+}
+
+// hsbToRGB converts nanoleafs hue (0-359), saturation (0-100) and brightness (0-100) into a color
+func hsbToRGB(hue, sat, bri float64) color.RGBA {
+	s, v := sat/100, bri/100
+	c := v * s
+	h := math.Mod(hue, 360) / 60
+	x := c * (1 - math.Abs(math.Mod(h, 2)-1))
+
+	var r, g, b float64
+	switch {
+	case h < 1:
+		r, g, b = c, x, 0
+	case h < 2:
+		r, g, b = x, c, 0
+	case h < 3:
+		r, g, b = 0, c, x
+	case h < 4:
+		r, g, b = 0, x, c
+	case h < 5:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	m := v - c
+	return color.RGBA{
+		R: uint8(math.Round((r + m) * 255)),
+		G: uint8(math.Round((g + m) * 255)),
+		B: uint8(math.Round((b + m) * 255)),
+		A: 0xff,
+	}
+}
@@ -0,0 +1,123 @@
+package nanoleaf
+
+import (
+	"encoding/json"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestPaletteValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		palette Palette
+		want    error
+	}{
+		{name: "valid", palette: Palette{{Hue: 359, Saturation: 100, Brightness: 100, Probability: 100}}},
+		{name: "without probability", palette: Palette{{Hue: 0, Saturation: 0, Brightness: 0}}},
+		{name: "empty", palette: Palette{}, want: ErrInvalidPalette},
+		{name: "hue", palette: Palette{{Hue: 360, Saturation: 50, Brightness: 50}}, want: ErrInvalidPalette},
+		{name: "negative hue", palette: Palette{{Hue: -1, Saturation: 50, Brightness: 50}}, want: ErrInvalidPalette},
+		{name: "saturation", palette: Palette{{Hue: 0, Saturation: 101, Brightness: 50}}, want: ErrInvalidPalette},
+		{name: "brightness", palette: Palette{{Hue: 0, Saturation: 50, Brightness: 101}}, want: ErrInvalidPalette},
+		{name: "negative probability", palette: Palette{{Hue: 0, Saturation: 50, Brightness: 50, Probability: -1}}, want: ErrInvalidPalette},
+		{name: "probability", palette: Palette{{Hue: 0, Saturation: 50, Brightness: 50, Probability: 100.5}}, want: ErrInvalidPalette},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.palette.Validate(); err != tt.want {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWeightedPalette(t *testing.T) {
+	red, blue := color.RGBA{R: 0xff, A: 0xff}, color.RGBA{B: 0xff, A: 0xff}
+
+	palette, err := NewWeightedPalette([]color.Color{red, blue}, []float64{3, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Palette{
+		{Hue: 0, Saturation: 100, Brightness: 100, Probability: 75},
+		{Hue: 240, Saturation: 100, Brightness: 100, Probability: 25},
+	}
+
+	for i := range want {
+		if got := palette[i]; got.Hue != want[i].Hue || got.Saturation != want[i].Saturation ||
+			got.Brightness != want[i].Brightness || math.Abs(got.Probability-want[i].Probability) > 1e-9 {
+			t.Errorf("got %+v, want %+v", got, want[i])
+		}
+	}
+
+	for _, weights := range [][]float64{{1}, {1, -1}, {0, 0}} {
+		if _, err := NewWeightedPalette([]color.Color{red, blue}, weights); err != ErrInvalidPalette {
+			t.Errorf("weights %v: got %v, want %v", weights, err, ErrInvalidPalette)
+		}
+	}
+
+	if _, err := NewPalette(); err != ErrInvalidPalette {
+		t.Errorf("empty palette: got %v, want %v", err, ErrInvalidPalette)
+	}
+}
+
+func TestPaletteEffectValidate(t *testing.T) {
+	palette := Palette{{Hue: 120, Saturation: 100, Brightness: 100}}
+	timing := TimeRange{Min: 5, Max: 10}
+	brightness := &BrightnessRange{Min: 20, Max: 100}
+
+	tests := []struct {
+		name   string
+		effect PaletteEffect
+		want   error
+	}{
+		{name: "random", effect: NewRandomEffect("a", palette, timing, timing, nil)},
+		{name: "random with brightness", effect: NewRandomEffect("a", palette, timing, timing, brightness)},
+		{name: "fade", effect: NewFadeEffect("a", palette, timing, timing, true)},
+		{name: "highlight", effect: NewHighlightEffect("a", palette, timing, timing, brightness)},
+		{name: "highlight without brightness", effect: NewHighlightEffect("a", palette, timing, timing, nil), want: ErrInvalidBrightnessRange},
+		{name: "brightness out of range", effect: NewRandomEffect("a", palette, timing, timing, &BrightnessRange{Min: 0, Max: 101}), want: ErrInvalidBrightnessRange},
+		{name: "brightness min above max", effect: NewRandomEffect("a", palette, timing, timing, &BrightnessRange{Min: 60, Max: 40}), want: ErrInvalidBrightnessRange},
+		{name: "invalid palette", effect: NewFadeEffect("a", Palette{}, timing, timing, true), want: ErrInvalidPalette},
+		{name: "transTime", effect: NewFadeEffect("a", palette, TimeRange{Min: 10, Max: 5}, timing, true), want: ErrInvalidTiming},
+		{name: "delayTime", effect: NewFadeEffect("a", palette, timing, TimeRange{Min: -1, Max: 5}, true), want: ErrInvalidTiming},
+		{name: "flow", effect: NewFlowEffect("a", palette, timing, timing, DirectionOutwards, 1.5, true)},
+		{name: "flow direction", effect: NewFlowEffect("a", palette, timing, timing, "sideways", 1.5, true), want: ErrInvalidDirection},
+		{name: "flow factor", effect: NewFlowEffect("a", palette, timing, timing, DirectionLeft, 0, true), want: ErrInvalidFlowFactor},
+		{name: "wheel", effect: NewWheelEffect("a", palette, timing, timing, DirectionUp, 2, true)},
+		{name: "wheel direction", effect: NewWheelEffect("a", palette, timing, timing, DirectionInwards, 2, true), want: ErrInvalidDirection},
+		{name: "wheel window size", effect: NewWheelEffect("a", palette, timing, timing, DirectionUp, 0, true), want: ErrInvalidWindowSize},
+		{name: "explode", effect: NewExplodeEffect("a", palette, timing, timing, DirectionInwards, 0.5, false)},
+		{name: "explode direction", effect: NewExplodeEffect("a", palette, timing, timing, DirectionLeft, 0.5, false), want: ErrInvalidDirection},
+		{name: "explode factor", effect: NewExplodeEffect("a", palette, timing, timing, DirectionOutwards, -1, false), want: ErrInvalidExplodeFactor},
+		{name: "anim type", effect: PaletteEffect{Type: "plugin", Palette: palette, TransTime: timing, DelayTime: timing}, want: ErrInvalidAnimType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.effect.Validate(); err != tt.want {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaletteEffectPayload(t *testing.T) {
+	effect := NewFadeEffect("Calm", Palette{{Hue: 120, Saturation: 100, Brightness: 100}}, TimeRange{Min: 5, Max: 10}, TimeRange{Min: 5, Max: 10}, true)
+
+	data, err := json.Marshal(effect.payload("display"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"write":{"command":"display","animName":"Calm","animType":"fade","colorType":"HSB",` +
+		`"palette":[{"hue":120,"saturation":100,"brightness":100}],"transTime":{"maxValue":10,"minValue":5},` +
+		`"delayTime":{"maxValue":10,"minValue":5},"loop":true}}`
+
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,190 @@
+package nanoleaf
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/cmplx"
+)
+
+// wave format tags
+const (
+	wavFormatPCM        = 1
+	wavFormatExtensible = 0xfffe
+)
+
+// PCMFormat describes interleaved signed little endian pcm samples (8 bit samples are unsigned like in wav files)
+type PCMFormat struct {
+	SampleRate    int
+	Channels      int
+	BitsPerSample int
+}
+
+// PCMReader reads pcm samples and mixes them down to mono floats between -1 and 1
+type PCMReader struct {
+	r      io.Reader
+	format PCMFormat
+	buf    []byte
+}
+
+// NewPCMReader returns a reader for raw pcm data of the given format
+func NewPCMReader(r io.Reader, format PCMFormat) (*PCMReader, error) {
+	if format.SampleRate <= 0 || format.Channels <= 0 {
+		return nil, ErrUnsupportedAudio
+	}
+
+	switch format.BitsPerSample {
+	case 8, 16, 24, 32:
+	default:
+		return nil, ErrUnsupportedAudio
+	}
+
+	return &PCMReader{r: r, format: format}, nil
+}
+
+// NewWAVReader parses the wav header of r and returns a reader positioned at the first sample
+func NewWAVReader(r io.Reader) (*PCMReader, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, ErrUnsupportedAudio
+	}
+
+	var format PCMFormat
+	hasFormat := false
+
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, err
+		}
+
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+
+		switch id {
+		case "fmt ":
+			data := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return nil, err
+			}
+
+			if size < 16 {
+				return nil, ErrUnsupportedAudio
+			}
+
+			tag := binary.LittleEndian.Uint16(data[0:2])
+			if tag != wavFormatPCM && tag != wavFormatExtensible {
+				return nil, ErrUnsupportedAudio
+			}
+
+			format.Channels = int(binary.LittleEndian.Uint16(data[2:4]))
+			format.SampleRate = int(binary.LittleEndian.Uint32(data[4:8]))
+			format.BitsPerSample = int(binary.LittleEndian.Uint16(data[14:16]))
+			hasFormat = true
+		case "data":
+			if !hasFormat {
+				return nil, ErrUnsupportedAudio
+			}
+
+			return NewPCMReader(io.LimitReader(r, size), format)
+		default:
+			// chunks are padded to an even size
+			if _, err := io.CopyN(ioutil.Discard, r, size+size%2); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// Format returns the format of the underlying pcm data
+func (p *PCMReader) Format() PCMFormat {
+	return p.format
+}
+
+// Read fills samples with mono samples and returns how many have been read
+func (p *PCMReader) Read(samples []float64) (int, error) {
+	width := p.format.BitsPerSample / 8
+	frame := width * p.format.Channels
+
+	if size := len(samples) * frame; cap(p.buf) < size {
+		p.buf = make([]byte, size)
+	}
+
+	buf := p.buf[:len(samples)*frame]
+	n, err := io.ReadFull(p.r, buf)
+	frames := n / frame
+
+	for i := 0; i < frames; i++ {
+		sum := 0.0
+		for c := 0; c < p.format.Channels; c++ {
+			offset := i*frame + c*width
+			sum += decodeSample(buf[offset:offset+width], p.format.BitsPerSample)
+		}
+
+		samples[i] = sum / float64(p.format.Channels)
+	}
+
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+
+	if frames > 0 && err == io.EOF {
+		err = nil
+	}
+
+	return frames, err
+}
+
+// decodeSample decodes a single little endian sample into a float between -1 and 1
+func decodeSample(b []byte, bits int) float64 {
+	switch bits {
+	case 8:
+		return (float64(b[0]) - 128) / 128
+	case 16:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / 32768
+	case 24:
+		v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+		return float64(v) / 8388608
+	case 32:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648
+	}
+
+	return 0
+}
+
+// fft computes the discrete fourier transform of x in place. len(x) has to be a power of two
+func fft(x []complex128) {
+	n := len(x)
+
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+
+			for k := 0; k < size/2; k++ {
+				even, odd := x[start+k], x[start+k+size/2]*w
+				x[start+k] = even + odd
+				x[start+k+size/2] = even - odd
+				w *= step
+			}
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package nanoleaf
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// CanvasMember a controller taking part in a virtual canvas
+type CanvasMember struct {
+	Stream *NanoStream
+	Layout *PanelLayout
+	// Offset of the controllers layout origin in canvas space
+	Offset Point
+	// Rotation of the controllers layout around its origin in degrees (counter clockwise)
+	Rotation float64
+}
+
+// VirtualPanel a panel of a virtual canvas
+type VirtualPanel struct {
+	ID       int   `json:"id"`
+	Member   int   `json:"member"`
+	PanelID  int   `json:"panelId"`
+	Position Point `json:"position"`
+}
+
+// Canvas combines the panels of several controllers into one virtual layout driven by one framebuffer.
+// Virtual panel ids are assigned in member order starting at 1
+type Canvas struct {
+	members []CanvasMember
+	panels  []VirtualPanel
+	byID    map[int]VirtualPanel
+	layout  PanelLayout
+	fb      *Framebuffer
+}
+
+// NewCanvas returns a canvas of the given members
+func NewCanvas(members ...CanvasMember) *Canvas {
+	c := &Canvas{
+		members: members,
+		byID:    map[int]VirtualPanel{},
+	}
+
+	for m, member := range members {
+		for _, panel := range member.Layout.PositionData {
+			position := rotatePoint(Point{X: float64(panel.X), Y: float64(panel.Y)}, Point{}, member.Rotation)
+			position.X += member.Offset.X
+			position.Y += member.Offset.Y
+
+			virtual := VirtualPanel{
+				ID:       len(c.panels) + 1,
+				Member:   m,
+				PanelID:  panel.ID,
+				Position: position,
+			}
+
+			c.panels = append(c.panels, virtual)
+			c.byID[virtual.ID] = virtual
+			c.layout.PositionData = append(c.layout.PositionData, PanelPositionData{
+				ID:        virtual.ID,
+				X:         int(math.Round(position.X)),
+				Y:         int(math.Round(position.Y)),
+				O:         normalizeDegrees(float64(panel.O) + member.Rotation),
+				ShapeType: panel.ShapeType,
+			})
+		}
+
+		if member.Layout.SideLength > c.layout.SideLength {
+			c.layout.SideLength = member.Layout.SideLength
+		}
+	}
+
+	c.layout.Panels = len(c.layout.PositionData)
+	c.fb = NewFramebuffer(&c.layout)
+
+	return c
+}
+
+// Layout returns the combined layout using virtual panel ids. It works with every layout helper,
+// e.g. NewImageSampler, Geometry or the spatial selections
+func (c *Canvas) Layout() *PanelLayout {
+	return &c.layout
+}
+
+// Framebuffer returns the framebuffer of the canvas keyed by virtual panel id
+func (c *Canvas) Framebuffer() *Framebuffer {
+	return c.fb
+}
+
+// Panels returns every virtual panel
+func (c *Canvas) Panels() []VirtualPanel {
+	panels := make([]VirtualPanel, len(c.panels))
+	copy(panels, c.panels)
+	return panels
+}
+
+// Panel returns the virtual panel of the given virtual id
+func (c *Canvas) Panel(id int) (VirtualPanel, bool) {
+	panel, ok := c.byID[id]
+	return panel, ok
+}
+
+// Split splits an effect using virtual panel ids into one effect per member using the members panel ids
+func (c *Canvas) Split(effect StreamEffect) []StreamEffect {
+	effects := make([]StreamEffect, len(c.members))
+
+	for _, panel := range effect.Panels {
+		virtual, ok := c.byID[panel.ID]
+		if !ok {
+			continue
+		}
+
+		effects[virtual.Member].Panels = append(effects[virtual.Member].Panels, PanelEffect{
+			ID:     virtual.PanelID,
+			Frames: panel.Frames,
+		})
+	}
+
+	return effects
+}
+
+// Flush writes the changed panels of the framebuffer (or every panel if full is set) to all controllers at once.
+// Panels of controllers failing to write stay marked as changed
+func (c *Canvas) Flush(full bool) error {
+	flushed := c.fb.Flush(full)
+	effects := c.Split(flushed)
+	errs := make([]error, len(effects))
+
+	var wg sync.WaitGroup
+	for i, effect := range effects {
+		if len(effect.Panels) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, effect StreamEffect) {
+			defer wg.Done()
+			errs[i] = c.members[i].Stream.WriteEffect(effect)
+		}(i, effect)
+	}
+
+	wg.Wait()
+
+	var failed []int
+	for _, id := range panelIDs(flushed) {
+		if errs[c.byID[id].Member] != nil {
+			failed = append(failed, id)
+		}
+	}
+
+	c.fb.markDirty(failed...)
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run flushes the framebuffer fps times per second until ctx is cancelled or a write fails.
+// The first tick sends every panel
+func (c *Canvas) Run(ctx context.Context, fps int) error {
+	if fps <= 0 {
+		fps = DefaultRendererFPS
+	}
+
+	ticker := time.NewTicker(time.Second / time.Duration(fps))
+	defer ticker.Stop()
+
+	full := true
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := c.Flush(full); err != nil {
+				return err
+			}
+
+			full = false
+		}
+	}
+}
+
+// normalizeDegrees rounds an angle and brings it into 0-359
+func normalizeDegrees(degrees float64) int {
+	o := int(math.Round(math.Mod(degrees, 360)))
+	if o < 0 {
+		o += 360
+	}
+
+	return o % 360
+}
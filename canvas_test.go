@@ -0,0 +1,137 @@
+package nanoleaf
+
+import (
+	"image/color"
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+// udpStream returns a v2 stream connected to a loopback listener and the listener
+func udpStream(t *testing.T) (*NanoStream, *net.UDPConn) {
+	t.Helper()
+
+	listener, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := NewNanoleaf("http://127.0.0.1:16021/api/v1").Stream
+	s.address = "127.0.0.1"
+	s.port = listener.LocalAddr().(*net.UDPAddr).Port
+	s.version = StreamVersionV2
+
+	if err := s.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Disconnect() })
+
+	return s, listener
+}
+
+// pointNear reports if a and b are equal apart from rounding errors
+func pointNear(a, b Point) bool {
+	return math.Abs(a.X-b.X) < 1e-9 && math.Abs(a.Y-b.Y) < 1e-9
+}
+
+// twoPanels returns a layout of two triangles and a controller unit
+func twoPanels() *PanelLayout {
+	return &PanelLayout{
+		Panels:     3,
+		SideLength: 150,
+		PositionData: []PanelPositionData{
+			{ID: 11, X: 0, Y: 0, O: 0, ShapeType: ShapeTriangle},
+			{ID: 12, X: 150, Y: 0, O: 60, ShapeType: ShapeTriangle},
+			{ID: 0, X: 75, Y: -40, O: 0, ShapeType: ShapeShapesController},
+		},
+	}
+}
+
+func TestNewCanvas(t *testing.T) {
+	c := NewCanvas(
+		CanvasMember{Layout: twoPanels()},
+		CanvasMember{Layout: twoPanels(), Offset: Point{X: 1000}, Rotation: -90},
+	)
+
+	panels := c.Panels()
+	if len(panels) != 6 {
+		t.Fatalf("got %d panels, want 6", len(panels))
+	}
+
+	want := VirtualPanel{ID: 5, Member: 1, PanelID: 12, Position: Point{X: 1000, Y: -150}}
+	if got, _ := c.Panel(5); !pointNear(got.Position, want.Position) || got.Member != want.Member || got.PanelID != want.PanelID {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	orientations := map[int]int{1: 0, 2: 60, 4: 270, 5: 330}
+	for _, panel := range c.Layout().PositionData {
+		if o, ok := orientations[panel.ID]; ok && panel.O != o {
+			t.Errorf("panel %d: got orientation %d, want %d", panel.ID, panel.O, o)
+		}
+	}
+
+	if ids := c.Framebuffer().Panels(); len(ids) != 4 {
+		t.Errorf("got framebuffer panels %v, controller units should be skipped", ids)
+	}
+}
+
+func TestNormalizeDegrees(t *testing.T) {
+	tests := map[float64]int{0: 0, 60: 60, -90: 270, 330 + 30: 0, -0.4: 0, 359.6: 0, 725: 5, -725: 355}
+
+	for degrees, want := range tests {
+		if got := normalizeDegrees(degrees); got != want {
+			t.Errorf("normalizeDegrees(%v) = %d, want %d", degrees, got, want)
+		}
+	}
+}
+
+func TestCanvasSplit(t *testing.T) {
+	c := NewCanvas(CanvasMember{Layout: twoPanels()}, CanvasMember{Layout: twoPanels()})
+
+	effects := c.Split(StreamEffect{Panels: []PanelEffect{{ID: 1}, {ID: 5}, {ID: 99}}})
+
+	if len(effects) != 2 || len(effects[0].Panels) != 1 || len(effects[1].Panels) != 1 {
+		t.Fatalf("got %+v", effects)
+	}
+
+	if effects[0].Panels[0].ID != 11 || effects[1].Panels[0].ID != 12 {
+		t.Errorf("got %+v, want panel 11 and 12", effects)
+	}
+}
+
+func TestCanvasFlushKeepsFailedPanelsDirty(t *testing.T) {
+	connected, listener := udpStream(t)
+	disconnected := NewNanoleaf("http://127.0.0.1:16021/api/v1").Stream
+
+	c := NewCanvas(
+		CanvasMember{Stream: connected, Layout: twoPanels()},
+		CanvasMember{Stream: disconnected, Layout: twoPanels()},
+	)
+
+	fb := c.Framebuffer()
+	for _, id := range fb.Panels() {
+		fb.Set(id, color.RGBA{R: 0xff, A: 0xff})
+	}
+
+	if err := c.Flush(false); err != ErrStreamNotConnected {
+		t.Fatalf("got %v, want %v", err, ErrStreamNotConnected)
+	}
+
+	listener.SetReadDeadline(time.Now().Add(time.Second))
+	packet := make([]byte, 64)
+	n, _, err := listener.ReadFromUDP(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2+2*8 {
+		t.Errorf("got a packet of %d bytes, want both panels of the connected member", n)
+	}
+
+	pending := panelIDs(fb.Flush(false))
+	if len(pending) != 2 || pending[0] != 4 || pending[1] != 5 {
+		t.Errorf("got pending panels %v, want 4 and 5", pending)
+	}
+}
@@ -0,0 +1,507 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/adnanbrq/nanoleaf"
+)
+
+func init() {
+	register("discover", "discover [-timeout 3s]", "find controllers via mDNS and SSDP", runDiscover)
+	register("pair", "pair [-name NAME] [-default] [URL]", "create a token (hold the power button for 5-7 seconds first)", runPair)
+	register("devices", "devices", "list paired devices", runDevices)
+	register("on", "on", "turn the panels on", runOnOff(true))
+	register("off", "off", "turn the panels off", runOnOff(false))
+	register("state", "state", "show the current state", runState)
+	register("brightness", "brightness [-duration seconds] VALUE|+N|-N", "set or change the brightness", runBrightness)
+	register("hue", "hue VALUE|+N|-N", "set or change the hue", runValue("hue"))
+	register("sat", "sat VALUE|+N|-N", "set or change the saturation", runValue("sat"))
+	register("ct", "ct VALUE|+N|-N", "set or change the color temperature", runValue("ct"))
+	register("effects", "effects list|get|set NAME|export NAME [FILE]|import FILE", "manage effects", runEffects)
+	register("layout", "layout show [-format ascii|svg|png] [-o FILE] [-cols N]", "show the panel layout", runLayout)
+	register("identify", "identify", "let the panels flash", runIdentify)
+	register("raw", "raw METHOD PATH [BODY]", "send a request below the authorized api url", runRaw)
+}
+
+// runDiscover prints every controller found on the local network
+func runDiscover(e *env, args []string) error {
+	flags := flag.NewFlagSet("discover", flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	timeout := flags.Duration("timeout", nanoleaf.DefaultDiscoveryTimeout, "how long to wait for answers")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return errUsage
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	controllers, err := nanoleaf.DiscoverAll(ctx)
+	if err != nil {
+		if len(controllers) == 0 {
+			return err
+		}
+
+		fmt.Fprintf(e.stderr, "warning: %v\n", err)
+	}
+
+	var b bytes.Buffer
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tMODEL\tURL\tDEVICE ID\tSOURCE")
+	for _, c := range controllers {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", c.Name, c.Model, c.URL(), c.DeviceID, c.Source)
+	}
+	w.Flush()
+
+	e.print(controllers, b.String())
+	return nil
+}
+
+// runPair authenticates against a controller and stores the token
+func runPair(e *env, args []string) error {
+	flags := flag.NewFlagSet("pair", flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	name := flags.String("name", "", "name to store the device as (defaults to the controllers name)")
+	makeDefault := flags.Bool("default", false, "use the device by default")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() > 1 {
+		return errUsage
+	}
+
+	url := flags.Arg(0)
+	if url == "" {
+		url = e.url
+	}
+
+	if url == "" {
+		ctx, cancel := context.WithTimeout(context.Background(), nanoleaf.DefaultDiscoveryTimeout)
+		defer cancel()
+
+		controllers, err := nanoleaf.DiscoverAll(ctx)
+		if err != nil && len(controllers) == 0 {
+			return err
+		}
+
+		if len(controllers) == 0 {
+			return fmt.Errorf("no controller found, please pass its url")
+		}
+
+		url = controllers[0].URL()
+	}
+
+	n := nanoleaf.NewNanoleaf(strings.TrimSuffix(url, "/"))
+	if err := n.Auth.Authenticate(); err != nil {
+		return err
+	}
+
+	if *name == "" {
+		info, err := n.GetControllerInfo()
+		if err != nil {
+			return err
+		}
+
+		*name = info.Name
+	}
+
+	e.cfg.Devices[*name] = device{URL: url, Token: n.GetToken()}
+	if *makeDefault || e.cfg.Default == "" {
+		e.cfg.Default = *name
+	}
+
+	if err := e.cfg.save(); err != nil {
+		return err
+	}
+
+	e.print(map[string]string{"name": *name, "url": url}, fmt.Sprintf("paired %s (%s)", *name, url))
+	return nil
+}
+
+// runDevices lists the paired devices
+func runDevices(e *env, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	type entry struct {
+		Name    string `json:"name"`
+		URL     string `json:"url"`
+		Default bool   `json:"default"`
+	}
+
+	var b bytes.Buffer
+	entries := []entry{}
+	for _, name := range e.cfg.names() {
+		entries = append(entries, entry{Name: name, URL: e.cfg.Devices[name].URL, Default: name == e.cfg.Default})
+
+		marker := " "
+		if name == e.cfg.Default {
+			marker = "*"
+		}
+		fmt.Fprintf(&b, "%s %s\t%s\n", marker, name, e.cfg.Devices[name].URL)
+	}
+
+	e.print(entries, b.String())
+	return nil
+}
+
+// runOnOff returns a command turning the panels on or off
+func runOnOff(on bool) func(e *env, args []string) error {
+	return func(e *env, args []string) error {
+		if len(args) != 0 {
+			return errUsage
+		}
+
+		n, err := e.client()
+		if err != nil {
+			return err
+		}
+
+		if err := n.State.SetOn(on); err != nil {
+			return err
+		}
+
+		e.ok()
+		return nil
+	}
+}
+
+// runState prints the current state
+func runState(e *env, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	n, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	info, err := n.GetControllerInfo()
+	if err != nil {
+		return err
+	}
+
+	s := info.State
+	text := fmt.Sprintf("name:       %s (%s, firmware %s)\non:         %t\nbrightness: %d\nhue:        %d\nsat:        %d\nct:         %d\ncolorMode:  %s\neffect:     %s",
+		info.Name, info.Model, info.FirmwareVersion, s.On.Value, s.Brightness.Value, s.Hue.Value, s.Sat.Value, s.Ct.Value, s.ColorMode, info.Effects.Active)
+
+	e.print(info, text)
+	return nil
+}
+
+// runBrightness sets or changes the brightness
+func runBrightness(e *env, args []string) error {
+	flags := flag.NewFlagSet("brightness", flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	duration := flags.Int("duration", 0, "transition time (seconds)")
+
+	// a decrement like -10 would be taken for a flag, so it is removed before parsing
+	args, decrement := splitDecrement(args)
+
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	arg := decrement
+	if arg == "" && flags.NArg() == 1 {
+		arg = flags.Arg(0)
+	} else if arg == "" || flags.NArg() != 0 {
+		return errUsage
+	}
+
+	value, incremental, err := parseValue(arg)
+	if err != nil {
+		return errUsage
+	}
+
+	n, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	if incremental {
+		current, err := n.State.GetBrightness()
+		if err != nil {
+			return err
+		}
+
+		value += current.Value
+	}
+
+	if err := n.State.SetBrightness(value, *duration); err != nil {
+		return err
+	}
+
+	e.ok()
+	return nil
+}
+
+// runValue returns a command setting or changing hue, sat or ct
+func runValue(kind string) func(e *env, args []string) error {
+	return func(e *env, args []string) error {
+		if len(args) != 1 {
+			return errUsage
+		}
+
+		value, incremental, err := parseValue(args[0])
+		if err != nil {
+			return errUsage
+		}
+
+		n, err := e.client()
+		if err != nil {
+			return err
+		}
+
+		switch kind {
+		case "hue":
+			err = n.State.SetHue(value, incremental)
+		case "sat":
+			err = n.State.SetSaturation(value, incremental)
+		case "ct":
+			err = n.State.SetColorTemp(value, incremental)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		e.ok()
+		return nil
+	}
+}
+
+// runEffects lists, selects, exports and imports effects
+func runEffects(e *env, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	n, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		effects, err := n.Effects.List()
+		if err != nil {
+			return err
+		}
+
+		e.print(effects, strings.Join(effects, "\n"))
+	case args[0] == "get" && len(args) == 1:
+		effect, err := n.Effects.Get()
+		if err != nil {
+			return err
+		}
+
+		e.print(map[string]string{"effect": effect}, effect)
+	case args[0] == "set" && len(args) == 2:
+		if err := n.Effects.Set(args[1]); err != nil {
+			return err
+		}
+
+		e.ok()
+	case args[0] == "export" && (len(args) == 2 || len(args) == 3):
+		data, err := n.Effects.Export(args[1])
+		if err != nil {
+			return err
+		}
+
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, data, "", "  "); err != nil {
+			return nanoleaf.ErrParsingJSON
+		}
+		pretty.WriteByte('\n')
+
+		if len(args) == 3 {
+			return ioutil.WriteFile(args[2], pretty.Bytes(), 0644)
+		}
+
+		e.stdout.Write(pretty.Bytes())
+	case args[0] == "import" && len(args) == 2:
+		data, err := ioutil.ReadFile(args[1])
+		if err != nil {
+			return err
+		}
+
+		if err := n.Effects.Import(data); err != nil {
+			return err
+		}
+
+		e.ok()
+	default:
+		return errUsage
+	}
+
+	return nil
+}
+
+// runLayout prints the panel layout
+func runLayout(e *env, args []string) error {
+	if len(args) == 0 || args[0] != "show" {
+		return errUsage
+	}
+
+	flags := flag.NewFlagSet("layout", flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	format := flags.String("format", "ascii", "ascii, svg or png")
+	output := flags.String("o", "", "write to file instead of stdout")
+	cols := flags.Int("cols", 60, "width of the ascii output")
+
+	if err := flags.Parse(args[1:]); err != nil || flags.NArg() != 0 {
+		return errUsage
+	}
+
+	n, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	geometry, err := n.Layout.GetGeometry()
+	if err != nil {
+		return err
+	}
+
+	var b bytes.Buffer
+	opts := nanoleaf.DefaultRenderOptions()
+	opts.ShowIDs = true
+
+	switch *format {
+	case "ascii":
+		if e.json && *output == "" {
+			e.print(geometry, "")
+			return nil
+		}
+
+		b.WriteString(asciiLayout(*geometry, *cols))
+	case "svg":
+		err = geometry.SVG(&b, opts)
+	case "png":
+		opts.Scale = 2
+		err = geometry.PNG(&b, opts)
+	default:
+		return errUsage
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if *output != "" {
+		return ioutil.WriteFile(*output, b.Bytes(), 0644)
+	}
+
+	_, err = e.stdout.Write(b.Bytes())
+	return err
+}
+
+// runIdentify lets the panels flash
+func runIdentify(e *env, args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	n, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	if err := n.Identity.Flash(); err != nil {
+		return err
+	}
+
+	e.ok()
+	return nil
+}
+
+// runRaw sends a request and prints status and body. A body of "-" is read from stdin
+func runRaw(e *env, args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return errUsage
+	}
+
+	var body []byte
+	if len(args) == 3 {
+		body = []byte(args[2])
+
+		if args[2] == "-" {
+			data, err := ioutil.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+
+			body = data
+		}
+	}
+
+	n, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	status, resp, err := n.Raw(args[0], args[1], body)
+	if err != nil {
+		return err
+	}
+
+	if e.json {
+		var parsed interface{}
+		if json.Unmarshal(resp, &parsed) != nil {
+			parsed = string(resp)
+		}
+
+		e.print(map[string]interface{}{"status": status, "body": parsed}, "")
+		return nil
+	}
+
+	fmt.Fprintf(e.stdout, "%d\n%s\n", status, resp)
+	return nil
+}
+
+// splitDecrement removes the first or last argument if it is a decrement like -10
+func splitDecrement(args []string) ([]string, string) {
+	for _, i := range []int{0, len(args) - 1} {
+		if i >= 0 && isDecrement(args[i]) {
+			rest := append(append([]string{}, args[:i]...), args[i+1:]...)
+			return rest, args[i]
+		}
+	}
+
+	return args, ""
+}
+
+// isDecrement checks if arg is a minus followed by digits only
+func isDecrement(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' {
+		return false
+	}
+
+	for _, r := range arg[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseValue parses an absolute value or a relative +N / -N
+func parseValue(arg string) (int, bool, error) {
+	value, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return value, strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-"), nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// errNoDevice occurs if no device has been configured or selected
+var errNoDevice = errors.New("No device selected. Please pair a device or pass -url and -token")
+
+// device a paired controller
+type device struct {
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+// config persisted devices and tokens
+type config struct {
+	path    string
+	Default string            `json:"default"`
+	Devices map[string]device `json:"devices"`
+}
+
+// defaultConfigPath returns $NANOLEAF_CONFIG or nanoleaf/config.json in the users config directory
+func defaultConfigPath() string {
+	if path := os.Getenv("NANOLEAF_CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "nanoleaf.json"
+	}
+
+	return filepath.Join(dir, "nanoleaf", "config.json")
+}
+
+// loadConfig reads the config at path, a missing file results in an empty config
+func loadConfig(path string) (*config, error) {
+	cfg := &config{path: path, Devices: map[string]device{}}
+	data, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.Devices == nil {
+		cfg.Devices = map[string]device{}
+	}
+
+	return cfg, nil
+}
+
+// save writes the config, readable by the current user only since it contains tokens
+func (c *config) save() error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(c.path, append(data, '\n'), 0600)
+}
+
+// device returns the device of name or the default device if name is empty
+func (c *config) device(name string) (device, error) {
+	if name == "" {
+		name = c.Default
+	}
+
+	d, ok := c.Devices[name]
+	if !ok {
+		return d, errNoDevice
+	}
+
+	return d, nil
+}
+
+// names returns the names of all devices in alphabetical order
+func (c *config) names() []string {
+	names := make([]string, 0, len(c.Devices))
+
+	for name := range c.Devices {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/adnanbrq/nanoleaf"
+)
+
+// terminal characters are roughly twice as high as wide
+const cellAspect = 2.0
+
+// unlitColor color of panels without a known color
+var unlitColor = color.RGBA{R: 0x33, G: 0x33, B: 0x33, A: 0xff}
+
+// fills characters used to fill panels, neighbors never share the same fill
+var fills = []rune{'.', ':', '+', '~', '=', '*', '-'}
+
+// cell a single character of a drawn layout
+type cell struct {
+	r     rune
+	panel int
+	label bool
+}
+
+// drawLayout rasterizes geometry into a grid of cols columns. Every cell knows the panel it belongs to
+// and panel ids are printed at the panel centers
+func drawLayout(geometry nanoleaf.LayoutGeometry, cols int) [][]cell {
+	bounds := geometry.BoundingBox()
+	if cols <= 0 || bounds.Width() <= 0 {
+		return nil
+	}
+
+	fill := panelFills(geometry)
+	unit := bounds.Width() / float64(cols)
+	rows := int(math.Ceil(bounds.Height()/(unit*cellAspect))) + 1
+
+	grid := make([][]cell, rows)
+	for y := range grid {
+		grid[y] = make([]cell, cols)
+
+		for x := range grid[y] {
+			grid[y][x] = cell{r: ' ', panel: -1}
+			p := nanoleaf.Point{
+				X: bounds.Min.X + (float64(x)+0.5)*unit,
+				Y: bounds.Max.Y - (float64(y)+0.5)*unit*cellAspect,
+			}
+
+			for _, panel := range geometry.Panels {
+				if panel.Contains(p) {
+					grid[y][x] = cell{r: fill[panel.ID], panel: panel.ID}
+					break
+				}
+			}
+		}
+	}
+
+	for _, panel := range geometry.Panels {
+		x := int((panel.Center.X - bounds.Min.X) / unit)
+		y := int((bounds.Max.Y - panel.Center.Y) / (unit * cellAspect))
+
+		label := strconv.Itoa(panel.ID)
+		if panel.Shape.IsControllerUnit() {
+			label = "#"
+		}
+
+		x -= len(label) / 2
+		for i, r := range label {
+			if y >= 0 && y < rows && x+i >= 0 && x+i < cols {
+				grid[y][x+i] = cell{r: r, panel: panel.ID, label: true}
+			}
+		}
+	}
+
+	return grid
+}
+
+// asciiLayout returns the layout as plain text
+func asciiLayout(geometry nanoleaf.LayoutGeometry, cols int) string {
+	var b strings.Builder
+
+	for _, row := range drawLayout(geometry, cols) {
+		line := make([]rune, len(row))
+		for i, c := range row {
+			line[i] = c.r
+		}
+
+		b.WriteString(strings.TrimRight(string(line), " "))
+		b.WriteByte('\n')
+	}
+
+	return b.String()
+}
+
+// colorLayout returns the lines of grid drawn with ansi truecolor backgrounds. Cells bordering another panel
+// are darkened so neighbors sharing a color stay apart
+func colorLayout(grid [][]cell, colors map[int]color.RGBA) []string {
+	lines := make([]string, len(grid))
+
+	for y, row := range grid {
+		var b strings.Builder
+		last := ""
+
+		for x, c := range row {
+			style := "\x1b[0m"
+			r := ' '
+
+			if c.panel >= 0 {
+				bg, ok := colors[c.panel]
+				if !ok {
+					bg = unlitColor
+				}
+
+				if border(grid, x, y) {
+					bg = color.RGBA{R: bg.R / 2, G: bg.G / 2, B: bg.B / 2, A: bg.A}
+				}
+
+				fg := color.RGBA{A: 0xff}
+				if luminance(bg) < 0.5 {
+					fg = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+				}
+
+				style = fmt.Sprintf("\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm", fg.R, fg.G, fg.B, bg.R, bg.G, bg.B)
+			}
+
+			if c.label {
+				r = c.r
+				if _, ok := colors[c.panel]; !ok && c.r == '#' {
+					style = "\x1b[0;90m"
+				}
+			}
+
+			if style != last {
+				b.WriteString(style)
+				last = style
+			}
+
+			b.WriteRune(r)
+		}
+
+		b.WriteString("\x1b[0m")
+		lines[y] = b.String()
+	}
+
+	return lines
+}
+
+// border checks if the cell at x, y touches another panel on its right or below
+func border(grid [][]cell, x, y int) bool {
+	panel := grid[y][x].panel
+
+	if x+1 < len(grid[y]) && grid[y][x+1].panel >= 0 && grid[y][x+1].panel != panel {
+		return true
+	}
+
+	return y+1 < len(grid) && grid[y+1][x].panel >= 0 && grid[y+1][x].panel != panel
+}
+
+// luminance returns the relative luminance of c between 0 and 1
+func luminance(c color.RGBA) float64 {
+	return (0.2126*float64(c.R) + 0.7152*float64(c.G) + 0.0722*float64(c.B)) / 255
+}
+
+// panelFills assigns every panel a fill character different from its neighbors (greedy graph coloring)
+func panelFills(geometry nanoleaf.LayoutGeometry) map[int]rune {
+	graph := geometry.Graph(nanoleaf.DefaultAdjacencyTolerance)
+	fill := map[int]rune{}
+
+	for _, id := range graph.Panels() {
+		used := map[rune]bool{}
+		for _, neighbor := range graph.Neighbors(id) {
+			if r, ok := fill[neighbor]; ok {
+				used[r] = true
+			}
+		}
+
+		fill[id] = fills[0]
+		for _, r := range fills {
+			if !used[r] {
+				fill[id] = r
+				break
+			}
+		}
+	}
+
+	return fill
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/adnanbrq/nanoleaf/gateway"
+)
+
+func init() {
+	register("gateway", "gateway [-listen :16080] [-store FILE] [-key KEY] [-import]", "serve a token free json api for paired devices", runGateway)
+}
+
+// runGateway serves the gateway api until interrupted
+func runGateway(e *env, args []string) error {
+	flags := flag.NewFlagSet("gateway", flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	listen := flags.String("listen", ":16080", "address to listen on")
+	storePath := flags.String("store", filepath.Join(filepath.Dir(e.cfg.path), "gateway.json"), "file storing the devices of the gateway")
+	key := flags.String("key", os.Getenv("NANOLEAF_GATEWAY_KEY"), "api key clients have to send (generated if empty)")
+	importDevices := flags.Bool("import", false, "add the devices paired with this tool")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return errUsage
+	}
+
+	if *key == "" {
+		generated, err := gateway.GenerateKey()
+		if err != nil {
+			return err
+		}
+
+		*key = generated
+		fmt.Fprintf(e.stderr, "api key: %s\n", *key)
+	}
+
+	g, err := gateway.New(gateway.NewFileStore(*storePath), *key)
+	if err != nil {
+		return err
+	}
+
+	if *importDevices {
+		for _, name := range e.cfg.names() {
+			d := e.cfg.Devices[name]
+			if err := g.Add(gateway.Device{ID: name, URL: d.URL, Token: d.Token}); err != nil && err != gateway.ErrDeviceExists {
+				fmt.Fprintf(e.stderr, "skipping %s: %v\n", name, err)
+			}
+		}
+	}
+
+	server := &http.Server{Addr: *listen, Handler: g, ReadHeaderTimeout: 10 * time.Second}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	errs := make(chan error, 1)
+	go func() { errs <- server.ListenAndServe() }()
+
+	fmt.Fprintf(e.stderr, "serving %d devices on %s\n", len(g.Devices()), *listen)
+
+	select {
+	case err := <-errs:
+		return err
+	case <-signals:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return server.Shutdown(ctx)
+}
@@ -0,0 +1,212 @@
+// Command nanoleaf controls nanoleaf controllers from the shell
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/adnanbrq/nanoleaf"
+)
+
+// errUsage occurs if a command was called with wrong arguments
+var errUsage = errors.New("Invalid arguments")
+
+// env holds everything a command needs
+type env struct {
+	cfg        *config
+	deviceName string
+	url        string
+	token      string
+	json       bool
+	stdout     io.Writer
+	stderr     io.Writer
+}
+
+// command a subcommand of the cli
+type command struct {
+	usage string
+	help  string
+	run   func(e *env, args []string) error
+}
+
+// commands all subcommands by name
+var commands = map[string]command{}
+
+// register adds a subcommand
+func register(name, usage, help string, run func(e *env, args []string) error) {
+	commands[name] = command{usage: usage, help: help, run: run}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run parses global flags and dispatches to the subcommand, returning the exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("nanoleaf", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { usage(stderr, flags) }
+
+	configPath := flags.String("config", defaultConfigPath(), "path of the config file storing devices and tokens")
+	deviceName := flags.String("device", "", "name of the paired device to use (default device if empty)")
+	url := flags.String("url", os.Getenv("NANOLEAF_URL"), "api url, e.g. http://192.168.1.2:16021/api/v1 (overrides -device)")
+	token := flags.String("token", os.Getenv("NANOLEAF_TOKEN"), "auth token used together with -url")
+	asJSON := flags.Bool("json", false, "print results as json")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if flags.NArg() == 0 {
+		usage(stderr, flags)
+		return 2
+	}
+
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", flags.Arg(0))
+		usage(stderr, flags)
+		return 2
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to load config: %v\n", err)
+		return 1
+	}
+
+	e := &env{
+		cfg:        cfg,
+		deviceName: *deviceName,
+		url:        *url,
+		token:      *token,
+		json:       *asJSON,
+		stdout:     stdout,
+		stderr:     stderr,
+	}
+
+	if err := cmd.run(e, flags.Args()[1:]); err != nil {
+		if err == errUsage {
+			fmt.Fprintf(stderr, "usage: nanoleaf %s\n", cmd.usage)
+			return 2
+		}
+
+		if e.json {
+			e.print(map[string]string{"error": err.Error()}, "")
+		} else {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+		}
+
+		return 1
+	}
+
+	return 0
+}
+
+// usage prints global flags and every subcommand
+func usage(w io.Writer, flags *flag.FlagSet) {
+	fmt.Fprintln(w, "usage: nanoleaf [flags] <command> [arguments]")
+	fmt.Fprintln(w, "\nflags:")
+	flags.SetOutput(w)
+	flags.PrintDefaults()
+	fmt.Fprintln(w, "\ncommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %s\t%s\n", commands[name].usage, commands[name].help)
+	}
+	tw.Flush()
+}
+
+// client returns an authorized client for the selected device
+func (e *env) client() (*nanoleaf.Nanoleaf, error) {
+	url, token := e.url, e.token
+
+	if url == "" {
+		d, err := e.cfg.device(e.deviceName)
+		if err != nil {
+			return nil, err
+		}
+
+		url, token = d.URL, d.Token
+	}
+
+	if token == "" {
+		return nil, errNoDevice
+	}
+
+	n := nanoleaf.NewNanoleaf(strings.TrimSuffix(url, "/"))
+	n.SetToken(token)
+
+	return n, nil
+}
+
+// namedClient an authorized client and the name of its device
+type namedClient struct {
+	name   string
+	client *nanoleaf.Nanoleaf
+}
+
+// clients returns the device given by -url or -device, or every paired device otherwise
+func (e *env) clients() ([]namedClient, error) {
+	if e.url != "" || e.deviceName != "" {
+		n, err := e.client()
+		if err != nil {
+			return nil, err
+		}
+
+		name := e.deviceName
+		if name == "" {
+			name = "nanoleaf"
+		}
+
+		return []namedClient{{name: name, client: n}}, nil
+	}
+
+	var clients []namedClient
+	for _, name := range e.cfg.names() {
+		d := e.cfg.Devices[name]
+		n := nanoleaf.NewNanoleaf(strings.TrimSuffix(d.URL, "/"))
+		n.SetToken(d.Token)
+
+		clients = append(clients, namedClient{name: name, client: n})
+	}
+
+	if len(clients) == 0 {
+		return nil, errNoDevice
+	}
+
+	return clients, nil
+}
+
+// print writes v as json in json mode, text otherwise
+func (e *env) print(v interface{}, text string) {
+	if e.json {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(v)
+		return
+	}
+
+	if text != "" {
+		fmt.Fprintln(e.stdout, strings.TrimSuffix(text, "\n"))
+	}
+}
+
+// ok prints a confirmation for commands without output
+func (e *env) ok() {
+	e.print(map[string]bool{"ok": true}, "")
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/adnanbrq/nanoleaf/metrics"
+)
+
+func init() {
+	register("metrics", "metrics [-listen :9540] [-timeout 5s]", "serve prometheus metrics of paired devices on /metrics", runMetrics)
+}
+
+// runMetrics serves the metrics of the selected device (or every paired device) until interrupted
+func runMetrics(e *env, args []string) error {
+	flags := flag.NewFlagSet("metrics", flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	listen := flags.String("listen", ":9540", "address to listen on")
+	timeout := flags.Duration("timeout", metrics.DefaultScrapeTimeout, "how long to wait for every controller per scrape")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return errUsage
+	}
+
+	clients, err := e.clients()
+	if err != nil {
+		return err
+	}
+
+	exporter := metrics.NewExporter(*timeout)
+	for _, c := range clients {
+		exporter.Register(c.name, c.client)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+
+	server := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	errs := make(chan error, 1)
+	go func() { errs <- server.ListenAndServe() }()
+
+	fmt.Fprintf(e.stderr, "serving metrics of %d devices on %s/metrics\n", len(clients), *listen)
+
+	select {
+	case err := <-errs:
+		return err
+	case <-signals:
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return server.Shutdown(ctx)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/adnanbrq/nanoleaf/mqttbridge"
+)
+
+func init() {
+	register("mqtt", "mqtt [-broker tcp://localhost:1883] [-username USER] [-prefix nanoleaf] [-no-discovery]", "bridge paired devices to mqtt and home assistant", runMQTT)
+}
+
+// runMQTT bridges the selected device (or every paired device) to an mqtt broker until interrupted
+func runMQTT(e *env, args []string) error {
+	flags := flag.NewFlagSet("mqtt", flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	broker := flags.String("broker", "tcp://localhost:1883", "url of the mqtt broker")
+	username := flags.String("username", "", "mqtt username")
+	password := flags.String("password", os.Getenv("NANOLEAF_MQTT_PASSWORD"), "mqtt password")
+	clientID := flags.String("client-id", "nanoleaf-bridge", "mqtt client id")
+	prefix := flags.String("prefix", mqttbridge.DefaultTopicPrefix, "prefix of the state and command topics")
+	discoveryPrefix := flags.String("discovery-prefix", mqttbridge.DefaultDiscoveryPrefix, "home assistant discovery prefix")
+	noDiscovery := flags.Bool("no-discovery", false, "do not publish home assistant discovery configs")
+	interval := flags.Duration("interval", mqttbridge.DefaultPollInterval, "how often to poll the controllers besides listening to events")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return errUsage
+	}
+
+	clients, err := e.clients()
+	if err != nil {
+		return err
+	}
+
+	devices := make([]mqttbridge.Device, len(clients))
+	for i, c := range clients {
+		devices[i] = mqttbridge.Device{ID: c.name, Client: c.client}
+	}
+
+	mqttOpts := mqtt.NewClientOptions().
+		AddBroker(*broker).
+		SetClientID(*clientID).
+		SetUsername(*username).
+		SetPassword(*password)
+
+	bridge, err := mqttbridge.New(mqttOpts, mqttbridge.Options{
+		TopicPrefix:      *prefix,
+		DiscoveryPrefix:  *discoveryPrefix,
+		DisableDiscovery: *noDiscovery,
+		PollInterval:     *interval,
+	}, devices...)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	go func() {
+		for {
+			select {
+			case <-signals:
+				cancel()
+				return
+			case err := <-bridge.Errors():
+				fmt.Fprintf(e.stderr, "%s error: %v\n", time.Now().Format(time.RFC3339), err)
+			}
+		}
+	}()
+
+	fmt.Fprintf(e.stderr, "bridging %d devices to %s\n", len(devices), *broker)
+
+	if err := bridge.Run(ctx); err != context.Canceled {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"errors"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/adnanbrq/nanoleaf"
+)
+
+// errInvalidAnimData occurs if animData could not be parsed
+var errInvalidAnimData = errors.New("Invalid animData given")
+
+// previewColors approximates the colors of every panel from the controllers state. effect is the data of the
+// active effect and may be nil, static and custom effects use their first frame, palette effects spread
+// their palette from left to right. Panels of controllers which are off are left unlit
+func previewColors(info nanoleaf.ControllerInfo, effect *nanoleaf.EffectData) map[int]color.RGBA {
+	colors := map[int]color.RGBA{}
+	state := info.State
+	layout := info.PanelLayout.Layout
+	panels := layout.SortLeftToRight(info.PanelLayout.GlobalOrientation.Value)
+	bri := float64(state.Brightness.Value) / 100
+
+	if !state.On.Value {
+		return colors
+	}
+
+	switch {
+	case state.ColorMode == "hs":
+		c := hsbToRGB(float64(state.Hue.Value), float64(state.Sat.Value), float64(state.Brightness.Value))
+		for _, id := range panels {
+			colors[id] = c
+		}
+	case state.ColorMode == "ct":
+		c := scaleColor(kelvinToRGB(state.Ct.Value), bri)
+		for _, id := range panels {
+			colors[id] = c
+		}
+	case effect == nil:
+	case effect.Data != "":
+		anim, err := parseAnimData(effect.Data)
+		if err != nil {
+			break
+		}
+
+		for id, c := range nanoleaf.ColorsFromEffect(anim) {
+			colors[id] = scaleColor(c, bri)
+		}
+	case len(effect.Palette) > 0:
+		for i, id := range panels {
+			p := effect.Palette[i%len(effect.Palette)]
+			colors[id] = hsbToRGB(float64(p.Hue), float64(p.Saturation), float64(p.Brightness)*bri)
+		}
+	}
+
+	return colors
+}
+
+// kelvinToRGB approximates the color of a black body of the given temperature
+func kelvinToRGB(kelvin int) color.RGBA {
+	t := float64(kelvin) / 100
+	r, g, b := 255.0, 255.0, 255.0
+
+	if t > 66 {
+		r = 329.698727446 * math.Pow(t-60, -0.1332047592)
+		g = 288.1221695283 * math.Pow(t-60, -0.0755148492)
+	} else {
+		g = 99.4708025861*math.Log(t) - 161.1195681661
+	}
+
+	if t < 66 {
+		b = 0
+		if t > 19 {
+			b = 138.5177312231*math.Log(t-10) - 305.0447927307
+		}
+	}
+
+	clamp := func(v float64) uint8 {
+		return uint8(math.Max(0, math.Min(255, math.Round(v))))
+	}
+
+	return color.RGBA{R: clamp(r), G: clamp(g), B: clamp(b), A: 0xff}
+}
+
+// scaleColor scales the channels of c by f
+func scaleColor(c color.RGBA, f float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(math.Round(float64(c.R) * f)),
+		G: uint8(math.Round(float64(c.G) * f)),
+		B: uint8(math.Round(float64(c.B) * f)),
+		A: c.A,
+	}
+}
+
+// parseAnimData parses animData of static and custom effects, it is the reverse of StreamEffect.ToString
+func parseAnimData(data string) (nanoleaf.StreamEffect, error) {
+	var effect nanoleaf.StreamEffect
+	fields := strings.Fields(data)
+	pos := 0
+
+	next := func() (int, error) {
+		if pos >= len(fields) {
+			return 0, errInvalidAnimData
+		}
+
+		value, err := strconv.Atoi(fields[pos])
+		pos++
+
+		if err != nil {
+			return 0, errInvalidAnimData
+		}
+
+		return value, nil
+	}
+
+	panels, err := next()
+	if err != nil {
+		return effect, err
+	}
+
+	for i := 0; i < panels; i++ {
+		var panel nanoleaf.PanelEffect
+		var frames int
+
+		if panel.ID, err = next(); err != nil {
+			return effect, err
+		}
+
+		if frames, err = next(); err != nil {
+			return effect, err
+		}
+
+		for j := 0; j < frames; j++ {
+			values := make([]int, 5)
+			for k := range values {
+				if values[k], err = next(); err != nil {
+					return effect, err
+				}
+			}
+
+			panel.Frames = append(panel.Frames, nanoleaf.FrameEffect{Red: values[0], Green: values[1], Blue: values[2], Transition: values[4]})
+		}
+
+		effect.Panels = append(effect.Panels, panel)
+	}
+
+	return effect, nil
+}
+
+// hsbToRGB converts nanoleafs hue (0-359), saturation (0-100) and brightness (0-100) into a color
+func hsbToRGB(hue, sat, bri float64) color.RGBA {
+	s, v := sat/100, bri/100
+	c := v * s
+	h := math.Mod(hue, 360) / 60
+	x := c * (1 - math.Abs(math.Mod(h, 2)-1))
+
+	var r, g, b float64
+	switch {
+	case h < 1:
+		r, g, b = c, x, 0
+	case h < 2:
+		r, g, b = x, c, 0
+	case h < 3:
+		r, g, b = 0, c, x
+	case h < 4:
+		r, g, b = 0, x, c
+	case h < 5:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+
+	m := v - c
+	return color.RGBA{
+		R: uint8(math.Round((r + m) * 255)),
+		G: uint8(math.Round((g + m) * 255)),
+		B: uint8(math.Round((b + m) * 255)),
+		A: 0xff,
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// errNoTerminal occurs if stdin is not an interactive terminal
+var errNoTerminal = errors.New("Interactive terminal required (stty failed)")
+
+// keys with multi byte escape sequences
+const (
+	keyUp    = "up"
+	keyDown  = "down"
+	keyRight = "right"
+	keyLeft  = "left"
+)
+
+// terminal an interactive terminal switched into cbreak mode on the alternate screen
+type terminal struct {
+	out   io.Writer
+	state string
+}
+
+// openTerminal disables line buffering and echo and switches to the alternate screen
+func openTerminal(out io.Writer) (*terminal, error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, errNoTerminal
+	}
+
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		return nil, errNoTerminal
+	}
+
+	fmt.Fprint(out, "\x1b[?1049h\x1b[?25l")
+	return &terminal{out: out, state: state}, nil
+}
+
+// restore leaves the alternate screen and restores the previous terminal settings
+func (t *terminal) restore() {
+	fmt.Fprint(t.out, "\x1b[0m\x1b[?25h\x1b[?1049l")
+	stty(t.state)
+}
+
+// size returns columns and rows of the terminal, 80x24 if unknown
+func (t *terminal) size() (int, int) {
+	out, err := stty("size")
+	if err != nil {
+		return 80, 24
+	}
+
+	var rows, cols int
+	if _, err := fmt.Sscan(out, &rows, &cols); err != nil || rows <= 0 || cols <= 0 {
+		return 80, 24
+	}
+
+	return cols, rows
+}
+
+// readKeys sends every key pressed to keys until stdin is closed. Arrow keys are sent by name,
+// everything else as the pressed character
+func readKeys(in io.Reader, keys chan<- string) {
+	arrows := map[byte]string{'A': keyUp, 'B': keyDown, 'C': keyRight, 'D': keyLeft}
+	buf := make([]byte, 64)
+
+	for {
+		n, err := in.Read(buf)
+		if err != nil {
+			close(keys)
+			return
+		}
+
+		for i := 0; i < n; i++ {
+			if buf[i] == 0x1b && i+2 < n && (buf[i+1] == '[' || buf[i+1] == 'O') {
+				if name, ok := arrows[buf[i+2]]; ok {
+					keys <- name
+				}
+
+				i += 2
+				continue
+			}
+
+			keys <- string(buf[i])
+		}
+	}
+}
+
+// stty runs stty on the controlling terminal and returns its output
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// truncate cuts s to n runes
+func truncate(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+
+	return string(r[:n])
+}
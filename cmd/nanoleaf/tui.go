@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/adnanbrq/nanoleaf"
+)
+
+// tui step sizes of the keyboard controls
+const (
+	brightnessStep = 5
+	hueStep        = 10
+	satStep        = 10
+	ctStep         = 250
+)
+
+// tuiHelp key bindings shown below the layout
+const tuiHelp = "space on/off  ↑↓ brightness  ←→ hue  [] saturation  ,. color temp  n/p choose effect  enter apply  r refresh  q quit"
+
+func init() {
+	register("tui", "tui [-interval 10s]", "interactive view of the layout with live colors", runTUI)
+}
+
+// tui state of the interactive view
+type tui struct {
+	n        *nanoleaf.Nanoleaf
+	term     *terminal
+	mu       sync.Mutex
+	info     *nanoleaf.ControllerInfo
+	effect   *nanoleaf.EffectData
+	geometry nanoleaf.LayoutGeometry
+	cursor   int
+	active   string
+	live     bool
+	status   string
+	cols     int
+	rows     int
+}
+
+// runTUI draws the layout with its current colors and lets the user control the controller by keyboard.
+// Changes are picked up from the event stream, the controller is polled every interval in addition
+func runTUI(e *env, args []string) error {
+	flags := flag.NewFlagSet("tui", flag.ContinueOnError)
+	flags.SetOutput(e.stderr)
+	interval := flags.Duration("interval", 10*time.Second, "how often to poll the controller besides listening to events")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 || *interval <= 0 {
+		return errUsage
+	}
+
+	n, err := e.client()
+	if err != nil {
+		return err
+	}
+
+	t := &tui{n: n}
+	if err := t.refresh(); err != nil {
+		return err
+	}
+
+	t.term, err = openTerminal(e.stdout)
+	if err != nil {
+		return err
+	}
+	defer t.term.restore()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	keys := make(chan string, 16)
+	go readKeys(os.Stdin, keys)
+
+	redraw := make(chan struct{}, 1)
+	reload := make(chan struct{}, 1)
+	go t.listen(ctx, redraw, reload)
+
+	poll := time.NewTicker(*interval)
+	defer poll.Stop()
+
+	resize := time.NewTicker(time.Second)
+	defer resize.Stop()
+
+	t.cols, t.rows = t.term.size()
+	t.draw()
+
+	for {
+		select {
+		case <-signals:
+			return nil
+		case key, ok := <-keys:
+			if !ok || !t.handle(key, reload) {
+				return nil
+			}
+		case <-redraw:
+		case <-reload:
+			t.setStatus(t.refresh())
+		case <-poll.C:
+			t.setStatus(t.refresh())
+		case <-resize.C:
+			cols, rows := t.term.size()
+			if cols == t.cols && rows == t.rows {
+				continue
+			}
+
+			t.cols, t.rows = cols, rows
+		}
+
+		t.draw()
+	}
+}
+
+// refresh loads state, layout and the data of the active effect
+func (t *tui) refresh() error {
+	info, err := t.n.GetControllerInfo()
+	if err != nil {
+		return err
+	}
+
+	var effect *nanoleaf.EffectData
+	if info.State.ColorMode == "effect" && !strings.HasPrefix(info.Effects.Active, "*") {
+		if data, err := t.n.Effects.GetEffectData(info.Effects.Active); err == nil {
+			effect = &data
+		}
+	}
+
+	geometry := info.PanelLayout.Layout.Geometry(info.PanelLayout.GlobalOrientation.Value)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.active != info.Effects.Active {
+		t.cursor = indexOf(info.Effects.List, info.Effects.Active)
+		t.active = info.Effects.Active
+	}
+
+	t.info, t.effect, t.geometry = info, effect, geometry
+	return nil
+}
+
+// listen applies events to the shown state until ctx is done. Whenever the stream breaks, it is
+// reopened after a short delay while polling keeps the view up to date
+func (t *tui) listen(ctx context.Context, redraw, reload chan<- struct{}) {
+	for {
+		err := t.n.Events.Listen(ctx, func(event nanoleaf.Event) {
+			t.mu.Lock()
+			t.live = true
+			applied := t.info.ApplyEvent(event)
+			t.mu.Unlock()
+
+			if !applied || event.Type == nanoleaf.EventTypeEffects || event.Attr == nanoleaf.StateAttrColorMode {
+				notify(reload)
+			}
+
+			notify(redraw)
+		}, nanoleaf.EventTypeState, nanoleaf.EventTypeLayout, nanoleaf.EventTypeEffects)
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		t.mu.Lock()
+		t.live = false
+		t.status = fmt.Sprintf("event stream: %v", err)
+		t.mu.Unlock()
+		notify(redraw)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// handle runs the action bound to key and reports false if the user wants to quit
+func (t *tui) handle(key string, reload chan<- struct{}) bool {
+	t.mu.Lock()
+	state := t.info.State
+	list := t.info.Effects.List
+	t.mu.Unlock()
+
+	var err error
+	switch key {
+	case "q", "Q", "\x1b":
+		return false
+	case " ":
+		err = t.n.State.SetOn(!state.On.Value)
+		t.update(func(info *nanoleaf.ControllerInfo) { info.State.On.Value = !state.On.Value })
+	case keyUp, keyDown, "+", "-":
+		value := state.Brightness.Value + brightnessStep
+		if key == keyDown || key == "-" {
+			value = state.Brightness.Value - brightnessStep
+		}
+
+		value = clamp(value, state.Brightness.Min, state.Brightness.Max)
+		err = t.n.State.SetBrightness(value, 0)
+		t.update(func(info *nanoleaf.ControllerInfo) { info.State.Brightness.Value = value })
+	case keyLeft, keyRight:
+		value := state.Hue.Value + hueStep
+		if key == keyLeft {
+			value = state.Hue.Value - hueStep
+		}
+
+		value = (value + 360) % 360
+		err = t.n.State.SetHue(value, false)
+		t.update(func(info *nanoleaf.ControllerInfo) { info.State.Hue.Value, info.State.ColorMode = value, "hs" })
+	case "[", "]":
+		value := state.Sat.Value + satStep
+		if key == "[" {
+			value = state.Sat.Value - satStep
+		}
+
+		value = clamp(value, state.Sat.Min, state.Sat.Max)
+		err = t.n.State.SetSaturation(value, false)
+		t.update(func(info *nanoleaf.ControllerInfo) { info.State.Sat.Value, info.State.ColorMode = value, "hs" })
+	case ",", ".":
+		value := state.Ct.Value + ctStep
+		if key == "," {
+			value = state.Ct.Value - ctStep
+		}
+
+		value = clamp(value, state.Ct.Min, state.Ct.Max)
+		err = t.n.State.SetColorTemp(value, false)
+		t.update(func(info *nanoleaf.ControllerInfo) { info.State.Ct.Value, info.State.ColorMode = value, "ct" })
+	case "n", "p", "\t":
+		if len(list) == 0 {
+			break
+		}
+
+		t.mu.Lock()
+		if key == "p" {
+			t.cursor = (t.cursor - 1 + len(list)) % len(list)
+		} else {
+			t.cursor = (t.cursor + 1) % len(list)
+		}
+		t.mu.Unlock()
+	case "\r", "\n":
+		t.mu.Lock()
+		cursor := t.cursor
+		t.mu.Unlock()
+
+		if cursor < 0 || cursor >= len(list) {
+			break
+		}
+
+		if err = t.n.Effects.Set(list[cursor]); err == nil {
+			notify(reload)
+		}
+	case "r", "R":
+		err = t.refresh()
+	}
+
+	t.setStatus(err)
+	return true
+}
+
+// update changes the shown state right away instead of waiting for the next event
+func (t *tui) update(change func(info *nanoleaf.ControllerInfo)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	change(t.info)
+}
+
+// setStatus shows err below the layout, a nil error clears the status
+func (t *tui) setStatus(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status = ""
+	if err != nil {
+		t.status = err.Error()
+	}
+}
+
+// draw redraws the whole screen
+func (t *tui) draw() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	info, state := t.info, t.info.State
+	width := t.cols
+
+	power, mode := "off", state.ColorMode
+	if state.On.Value {
+		power = "on"
+	}
+
+	if t.live {
+		mode += "  ● live"
+	}
+
+	selected := ""
+	if t.cursor >= 0 && t.cursor < len(info.Effects.List) {
+		selected = fmt.Sprintf("‹ %s › %d/%d", info.Effects.List[t.cursor], t.cursor+1, len(info.Effects.List))
+	}
+
+	header := []string{
+		"\x1b[1m" + truncate(fmt.Sprintf("%s  %s  firmware %s", info.Name, info.Model, info.FirmwareVersion), width) + "\x1b[0m",
+		truncate(fmt.Sprintf("power %s  brightness %d  hue %d  sat %d  ct %dK  mode %s", power, state.Brightness.Value, state.Hue.Value, state.Sat.Value, state.Ct.Value, mode), width),
+		truncate(fmt.Sprintf("effect %s  select %s", info.Effects.Active, selected), width),
+		"",
+	}
+
+	footer := []string{"", truncate(tuiHelp, width)}
+	if t.status != "" {
+		footer = append(footer, "\x1b[31m"+truncate(t.status, width)+"\x1b[0m")
+	}
+
+	cols := width
+	space := t.rows - len(header) - len(footer)
+	grid := drawLayout(t.geometry, cols)
+	for i := 0; i < 3 && len(grid) > space && space > 0 && cols > 10; i++ {
+		cols = cols * space / len(grid)
+		grid = drawLayout(t.geometry, cols)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("\x1b[H")
+
+	for _, line := range header {
+		b.WriteString(line + "\x1b[K\n")
+	}
+
+	for _, line := range colorLayout(grid, previewColors(*info, t.effect)) {
+		b.WriteString(line + "\x1b[K\n")
+	}
+
+	for _, line := range footer {
+		b.WriteString(line + "\x1b[K\n")
+	}
+
+	b.WriteString("\x1b[J")
+	t.term.out.Write(b.Bytes())
+}
+
+// notify sends to c without blocking, pending notifications are merged
+func notify(c chan<- struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// clamp limits v to min and max, a max of zero means unlimited
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+
+	if max > 0 && v > max {
+		return max
+	}
+
+	return v
+}
+
+// indexOf returns the index of s in list or -1
+func indexOf(list []string, s string) int {
+	for i, entry := range list {
+		if entry == s {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,196 @@
+package nanoleaf
+
+import (
+	"math"
+	"sort"
+)
+
+// LayoutChange describes a panel present in both layouts
+type LayoutChange struct {
+	ID   int               `json:"panelId"`
+	From PanelPositionData `json:"from"`
+	To   PanelPositionData `json:"to"`
+}
+
+// LayoutDiff differences between two layout snapshots
+type LayoutDiff struct {
+	Added   []PanelPositionData `json:"added"`
+	Removed []PanelPositionData `json:"removed"`
+	Moved   []LayoutChange      `json:"moved"`
+	Rotated []LayoutChange      `json:"rotated"`
+}
+
+// IsEmpty checks if both layouts are equal
+func (d LayoutDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Moved) == 0 && len(d.Rotated) == 0
+}
+
+// DiffLayouts compares two layout snapshots by panel id. A panel can be moved and rotated at the same time
+func DiffLayouts(from, to *PanelLayout) LayoutDiff {
+	diff := LayoutDiff{
+		Added:   []PanelPositionData{},
+		Removed: []PanelPositionData{},
+		Moved:   []LayoutChange{},
+		Rotated: []LayoutChange{},
+	}
+
+	before := panelsByID(from)
+	after := panelsByID(to)
+
+	for _, id := range sortedIDs(before) {
+		old := before[id]
+		current, ok := after[id]
+
+		if !ok {
+			diff.Removed = append(diff.Removed, old)
+			continue
+		}
+
+		change := LayoutChange{ID: id, From: old, To: current}
+		if old.X != current.X || old.Y != current.Y {
+			diff.Moved = append(diff.Moved, change)
+		}
+
+		if old.O != current.O {
+			diff.Rotated = append(diff.Rotated, change)
+		}
+	}
+
+	for _, id := range sortedIDs(after) {
+		if _, ok := before[id]; !ok {
+			diff.Added = append(diff.Added, after[id])
+		}
+	}
+
+	return diff
+}
+
+// RemapPanels maps the panel ids of one layout onto the panels of another layout at the closest position.
+// Both layouts are aligned on their matching panels first: panels present in both layouts if there are any,
+// otherwise the offset lining up the most panels. Moving the whole arrangement or adding and removing single
+// panels keeps the mapping. Every panel is used at most once, panels without a counterpart are left out
+func RemapPanels(from, to *PanelLayout) map[int]int {
+	type pair struct {
+		from, to int
+		distance float64
+	}
+
+	source := from.positioned(0)
+	target := to.positioned(0)
+	offset := alignmentOffset(from, to, source, target)
+
+	pairs := make([]pair, 0, len(source)*len(target))
+	for _, s := range source {
+		for _, t := range target {
+			d := math.Hypot(s.p.X+offset.X-t.p.X, s.p.Y+offset.Y-t.p.Y)
+			pairs = append(pairs, pair{from: s.id, to: t.id, distance: d})
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].distance < pairs[j].distance
+	})
+
+	mapping := map[int]int{}
+	used := map[int]bool{}
+
+	for _, p := range pairs {
+		if _, ok := mapping[p.from]; ok || used[p.to] {
+			continue
+		}
+
+		mapping[p.from] = p.to
+		used[p.to] = true
+	}
+
+	return mapping
+}
+
+// RemapEffect returns effect with its panel ids replaced according to mapping. Unmapped panels are dropped
+func RemapEffect(effect StreamEffect, mapping map[int]int) StreamEffect {
+	remapped := StreamEffect{Panels: make([]PanelEffect, 0, len(effect.Panels))}
+
+	for _, panel := range effect.Panels {
+		id, ok := mapping[panel.ID]
+		if !ok {
+			continue
+		}
+
+		remapped.Panels = append(remapped.Panels, PanelEffect{ID: id, Frames: panel.Frames})
+	}
+
+	return remapped
+}
+
+// alignmentOffset returns the translation moving source onto target. Panels with the same id vote for their
+// offset, without common ids every pair of panels votes. The offset with the most votes wins, ties are decided
+// by the distance to the offset aligning both centroids and then by the smallest offset
+func alignmentOffset(from, to *PanelLayout, source, target []positionedPanel) Point {
+	type offset struct{ x, y float64 }
+
+	if len(source) == 0 || len(target) == 0 {
+		return Point{}
+	}
+
+	votes := map[offset]int{}
+	vote := func(s, t Point) {
+		votes[offset{x: math.Round(t.X - s.X), y: math.Round(t.Y - s.Y)}]++
+	}
+
+	positions := make(map[int]Point, len(target))
+	for _, t := range target {
+		positions[t.id] = t.p
+	}
+
+	for _, s := range source {
+		if p, ok := positions[s.id]; ok {
+			vote(s.p, p)
+		}
+	}
+
+	if len(votes) == 0 {
+		for _, s := range source {
+			for _, t := range target {
+				vote(s.p, t.p)
+			}
+		}
+	}
+
+	a, b := from.Centroid(0), to.Centroid(0)
+	centroids := Point{X: b.X - a.X, Y: b.Y - a.Y}
+
+	var best offset
+	bestVotes, bestDistance := 0, math.Inf(1)
+	for o, n := range votes {
+		d := math.Hypot(o.x-centroids.X, o.y-centroids.Y)
+		closer := d < bestDistance || (d == bestDistance && (o.x < best.x || (o.x == best.x && o.y < best.y)))
+		if n > bestVotes || (n == bestVotes && closer) {
+			best, bestVotes, bestDistance = o, n, d
+		}
+	}
+
+	return Point{X: best.x, Y: best.y}
+}
+
+// panelsByID returns the panels of layout keyed by id
+func panelsByID(layout *PanelLayout) map[int]PanelPositionData {
+	panels := make(map[int]PanelPositionData, len(layout.PositionData))
+
+	for _, panel := range layout.PositionData {
+		panels[panel.ID] = panel
+	}
+
+	return panels
+}
+
+// sortedIDs returns the keys of panels in ascending order
+func sortedIDs(panels map[int]PanelPositionData) []int {
+	ids := make([]int, 0, len(panels))
+
+	for id := range panels {
+		ids = append(ids, id)
+	}
+
+	sort.Ints(ids)
+	return ids
+}
@@ -0,0 +1,137 @@
+package nanoleaf
+
+import (
+	"testing"
+)
+
+// row returns a layout of squares with the given ids placed 100 units apart starting at x
+func row(x int, ids ...int) *PanelLayout {
+	layout := &PanelLayout{Panels: len(ids)}
+
+	for i, id := range ids {
+		layout.PositionData = append(layout.PositionData, PanelPositionData{ID: id, X: x + i*100, ShapeType: ShapeSquare})
+	}
+
+	return layout
+}
+
+// withPanel adds a square with the given id and position to layout
+func withPanel(layout *PanelLayout, id, x, y int) *PanelLayout {
+	layout.Panels++
+	layout.PositionData = append(layout.PositionData, PanelPositionData{ID: id, X: x, Y: y, ShapeType: ShapeSquare})
+	return layout
+}
+
+func TestDiffLayouts(t *testing.T) {
+	from := row(0, 1, 2, 3)
+	to := row(0, 1, 2, 4)
+	to.PositionData[0].O = 90
+	to.PositionData[1].Y = 50
+
+	diff := DiffLayouts(from, to)
+
+	if len(diff.Added) != 1 || diff.Added[0].ID != 4 {
+		t.Errorf("got added %+v, want panel 4", diff.Added)
+	}
+
+	if len(diff.Removed) != 1 || diff.Removed[0].ID != 3 {
+		t.Errorf("got removed %+v, want panel 3", diff.Removed)
+	}
+
+	if len(diff.Moved) != 1 || diff.Moved[0].ID != 2 {
+		t.Errorf("got moved %+v, want panel 2", diff.Moved)
+	}
+
+	if len(diff.Rotated) != 1 || diff.Rotated[0].ID != 1 {
+		t.Errorf("got rotated %+v, want panel 1", diff.Rotated)
+	}
+
+	if !DiffLayouts(from, row(0, 1, 2, 3)).IsEmpty() {
+		t.Error("got changes for identical layouts")
+	}
+}
+
+func TestRemapPanels(t *testing.T) {
+	tests := []struct {
+		name string
+		from *PanelLayout
+		to   *PanelLayout
+		want map[int]int
+	}{
+		{
+			name: "panel added",
+			from: row(0, 1, 2, 3),
+			to:   row(0, 1, 2, 3, 4),
+			want: map[int]int{1: 1, 2: 2, 3: 3},
+		},
+		{
+			name: "panel removed",
+			from: row(0, 1, 2, 3, 4),
+			to:   row(0, 1, 2, 3),
+			want: map[int]int{1: 1, 2: 2, 3: 3},
+		},
+		{
+			name: "arrangement moved",
+			from: row(0, 1, 2, 3),
+			to:   row(500, 1, 2, 3),
+			want: map[int]int{1: 1, 2: 2, 3: 3},
+		},
+		{
+			name: "new ids and panel added",
+			from: row(0, 1, 2, 3),
+			to:   withPanel(row(1000, 11, 12, 13), 14, 1100, 100),
+			want: map[int]int{1: 11, 2: 12, 3: 13},
+		},
+		{
+			name: "new ids and first panel removed",
+			from: row(0, 1, 2, 3, 4),
+			to:   row(1100, 12, 13, 14),
+			want: map[int]int{2: 12, 3: 13, 4: 14},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RemapPanels(tt.from, tt.to)
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+
+			for from, to := range tt.want {
+				if got[from] != to {
+					t.Errorf("got %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestRemapPanelsIgnoresMovedPanel(t *testing.T) {
+	from := row(0, 1, 2, 3, 4)
+	to := row(0, 1, 2, 3, 4)
+	to.PositionData[3].Y = 100
+
+	got := RemapPanels(from, to)
+
+	for id := 1; id <= 4; id++ {
+		if got[id] != id {
+			t.Errorf("got %v, want every panel mapped onto itself", got)
+			break
+		}
+	}
+}
+
+func TestRemapEffect(t *testing.T) {
+	effect := StreamEffect{Panels: []PanelEffect{
+		{ID: 1, Frames: []FrameEffect{{Red: 255}}},
+		{ID: 2, Frames: []FrameEffect{{Green: 255}}},
+	}}
+
+	remapped := RemapEffect(effect, map[int]int{1: 11})
+
+	if len(remapped.Panels) != 1 || remapped.Panels[0].ID != 11 || remapped.Panels[0].Frames[0].Red != 255 {
+		t.Errorf("got %+v, want panel 11 only", remapped)
+	}
+}
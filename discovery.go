@@ -0,0 +1,339 @@
+package nanoleaf
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// mDNS defaults
+const (
+	// MDNSService service advertised by nanoleaf controllers
+	MDNSService = "_nanoleafapi._tcp.local."
+	// MDNSAddress multicast address and port of mDNS
+	MDNSAddress = "224.0.0.251:5353"
+	// DefaultDiscoveryTimeout is used if the context passed to a discovery has no deadline
+	DefaultDiscoveryTimeout = 3 * time.Second
+	// DefaultAPIPort port of the nanoleaf api
+	DefaultAPIPort = 16021
+)
+
+// Discovery sources
+const (
+	DiscoverySourceMDNS = "mdns"
+	DiscoverySourceSSDP = "ssdp"
+)
+
+// DiscoveredController a controller found on the local network
+type DiscoveredController struct {
+	Name     string `json:"name"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Model    string `json:"model"`
+	DeviceID string `json:"deviceId"`
+	Firmware string `json:"firmwareVersion"`
+	Source   string `json:"source"`
+}
+
+// URL returns the api url of the controller as expected by NewNanoleaf
+func (d DiscoveredController) URL() string {
+	return fmt.Sprintf("http://%s/api/v1", net.JoinHostPort(d.Host, strconv.Itoa(d.Port)))
+}
+
+// Client returns a client for the controller. An empty token returns a client ready for NanoAuth
+func (d DiscoveredController) Client(token string) *Nanoleaf {
+	n := NewNanoleaf(d.URL())
+
+	if token != "" {
+		n.SetToken(token)
+	}
+
+	return n
+}
+
+// MDNSDiscoverer browses for controllers via mDNS. The query is sent via IPv4, controllers answering with
+// AAAA records only are reported with their IPv6 address. Link-local IPv6 addresses carry no zone
+type MDNSDiscoverer struct {
+	// Address the query is sent to, defaults to MDNSAddress. Point it at a local responder for tests
+	Address string
+	// Service browsed for, defaults to MDNSService
+	Service string
+}
+
+// Discover browses for controllers via mDNS until ctx is done (or DefaultDiscoveryTimeout passed)
+func Discover(ctx context.Context) ([]DiscoveredController, error) {
+	return (&MDNSDiscoverer{}).Discover(ctx)
+}
+
+// Discover sends a single mDNS query and collects every answer until ctx is done
+// (or DefaultDiscoveryTimeout passed if ctx has no deadline)
+func (m *MDNSDiscoverer) Discover(ctx context.Context) ([]DiscoveredController, error) {
+	address, service := m.Address, m.Service
+	if address == "" {
+		address = MDNSAddress
+	}
+
+	if service == "" {
+		service = MDNSService
+	}
+
+	name, err := dnsmessage.NewName(service)
+	if err != nil {
+		return nil, err
+	}
+
+	query, err := (&dnsmessage.Message{
+		Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}},
+	}).Pack()
+
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := net.ResolveUDPAddr("udp4", address)
+	if err != nil {
+		return nil, err
+	}
+
+	// queries from a port other than 5353 are answered with unicast (RFC 6762 6.7)
+	con, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer con.Close()
+
+	if _, err := con.WriteToUDP(query, target); err != nil {
+		return nil, err
+	}
+
+	records := newMDNSRecords()
+	err = readUntil(ctx, con, func(packet []byte, _ *net.UDPAddr) {
+		records.parse(packet)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return records.controllers(service), nil
+}
+
+// readUntil calls handle for every packet received on con until ctx is done
+func readUntil(ctx context.Context, con *net.UDPConn, handle func([]byte, *net.UDPAddr)) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(DefaultDiscoveryTimeout)
+	}
+
+	buf := make([]byte, 9000)
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// wake up regularly to notice cancelled contexts
+		wake := time.Now().Add(100 * time.Millisecond)
+		if wake.After(deadline) {
+			wake = deadline
+		}
+
+		if err := con.SetReadDeadline(wake); err != nil {
+			return err
+		}
+
+		n, from, err := con.ReadFromUDP(buf)
+		if err != nil {
+			if e, ok := err.(net.Error); ok && e.Timeout() {
+				if !time.Now().Before(deadline) {
+					return nil
+				}
+
+				continue
+			}
+
+			return err
+		}
+
+		handle(buf[:n], from)
+	}
+}
+
+// mdnsService collected records of a single service instance
+type mdnsService struct {
+	target string
+	port   int
+	txt    map[string]string
+}
+
+// mdnsInstance instance announced for a service by a PTR record
+type mdnsInstance struct {
+	service string
+	name    string
+}
+
+// mdnsRecords records collected from every mDNS answer. Names used as keys are lower case
+type mdnsRecords struct {
+	instances  []mdnsInstance
+	services   map[string]*mdnsService
+	addresses  map[string]net.IP
+	addresses6 map[string]net.IP
+}
+
+// newMDNSRecords returns an empty record collection
+func newMDNSRecords() *mdnsRecords {
+	return &mdnsRecords{
+		services:   map[string]*mdnsService{},
+		addresses:  map[string]net.IP{},
+		addresses6: map[string]net.IP{},
+	}
+}
+
+// service returns the service instance of name, creating it if necessary
+func (r *mdnsRecords) service(name string) *mdnsService {
+	s, ok := r.services[name]
+	if !ok {
+		s = &mdnsService{txt: map[string]string{}}
+		r.services[name] = s
+	}
+
+	return s
+}
+
+// parse collects the PTR, SRV, TXT, A and AAAA records of a packet, ignoring anything it does not understand
+func (r *mdnsRecords) parse(packet []byte) {
+	var p dnsmessage.Parser
+
+	header, err := p.Start(packet)
+	if err != nil || !header.Response {
+		return
+	}
+
+	if err := p.SkipAllQuestions(); err != nil {
+		return
+	}
+
+	next := []func() (dnsmessage.ResourceHeader, error){p.AnswerHeader, p.AuthorityHeader, p.AdditionalHeader}
+	skip := []func() error{p.SkipAnswer, p.SkipAuthority, p.SkipAdditional}
+
+	for section := range next {
+		for {
+			h, err := next[section]()
+			if err == dnsmessage.ErrSectionDone {
+				break
+			}
+
+			if err != nil {
+				return
+			}
+
+			owner := strings.ToLower(h.Name.String())
+
+			switch h.Type {
+			case dnsmessage.TypePTR:
+				ptr, err := p.PTRResource()
+				if err != nil {
+					return
+				}
+
+				r.addInstance(mdnsInstance{service: owner, name: ptr.PTR.String()})
+			case dnsmessage.TypeSRV:
+				srv, err := p.SRVResource()
+				if err != nil {
+					return
+				}
+
+				s := r.service(owner)
+				s.target = strings.ToLower(srv.Target.String())
+				s.port = int(srv.Port)
+			case dnsmessage.TypeTXT:
+				txt, err := p.TXTResource()
+				if err != nil {
+					return
+				}
+
+				s := r.service(owner)
+				for _, entry := range txt.TXT {
+					kv := strings.SplitN(entry, "=", 2)
+					if len(kv) == 2 {
+						s.txt[strings.ToLower(kv[0])] = kv[1]
+					}
+				}
+			case dnsmessage.TypeA:
+				a, err := p.AResource()
+				if err != nil {
+					return
+				}
+
+				r.addresses[owner] = net.IP(a.A[:])
+			case dnsmessage.TypeAAAA:
+				aaaa, err := p.AAAAResource()
+				if err != nil {
+					return
+				}
+
+				r.addresses6[owner] = net.IP(aaaa.AAAA[:])
+			default:
+				if err := skip[section](); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// controllers returns a controller for every instance of service with a known SRV record
+func (r *mdnsRecords) controllers(service string) []DiscoveredController {
+	controllers := []DiscoveredController{}
+	service = strings.ToLower(service)
+
+	for _, instance := range r.instances {
+		if instance.service != service {
+			continue
+		}
+
+		s, ok := r.services[strings.ToLower(instance.name)]
+		if !ok || s.port == 0 {
+			continue
+		}
+
+		name := instance.name
+		if len(name) > len(service) && strings.EqualFold(name[len(name)-len(service):], service) {
+			name = name[:len(name)-len(service)-1]
+		}
+
+		host := strings.TrimSuffix(s.target, ".")
+		if ip, ok := r.addresses[s.target]; ok {
+			host = ip.String()
+		} else if ip, ok := r.addresses6[s.target]; ok {
+			host = ip.String()
+		}
+
+		controllers = append(controllers, DiscoveredController{
+			Name:     name,
+			Host:     host,
+			Port:     s.port,
+			Model:    s.txt["md"],
+			DeviceID: s.txt["id"],
+			Firmware: s.txt["srcvers"],
+			Source:   DiscoverySourceMDNS,
+		})
+	}
+
+	return controllers
+}
+
+// addInstance records an instance unless it is already known
+func (r *mdnsRecords) addInstance(instance mdnsInstance) {
+	for _, known := range r.instances {
+		if known.service == instance.service && strings.EqualFold(known.name, instance.name) {
+			return
+		}
+	}
+
+	r.instances = append(r.instances, instance)
+}
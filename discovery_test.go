@@ -0,0 +1,141 @@
+package nanoleaf
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// mdnsResponder answers every PTR query for MDNSService on loopback with the given records
+func mdnsResponder(t *testing.T, records ...dnsmessage.Resource) string {
+	t.Helper()
+
+	con, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { con.Close() })
+
+	go func() {
+		buf := make([]byte, 9000)
+
+		for {
+			n, from, err := con.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:n]); err != nil || len(query.Questions) != 1 {
+				t.Errorf("invalid query: %v", err)
+				continue
+			}
+
+			q := query.Questions[0]
+			if q.Type != dnsmessage.TypePTR || q.Name.String() != MDNSService {
+				t.Errorf("unexpected question %v", q)
+				continue
+			}
+
+			answer := dnsmessage.Message{
+				Header:  dnsmessage.Header{Response: true, Authoritative: true},
+				Answers: records,
+			}
+
+			packet, err := answer.Pack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			con.WriteToUDP(packet, from)
+		}
+	}()
+
+	return con.LocalAddr().String()
+}
+
+// resource returns a resource record of name in class IN
+func resource(name string, body dnsmessage.ResourceBody) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Class: dnsmessage.ClassINET, TTL: 120},
+		Body:   body,
+	}
+}
+
+func TestMDNSDiscover(t *testing.T) {
+	canvas := "Canvas 7E3A." + MDNSService
+	shapes := "Shapes AC09." + MDNSService
+
+	address := mdnsResponder(t,
+		resource(MDNSService, &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName(canvas)}),
+		resource(canvas, &dnsmessage.SRVResource{Target: dnsmessage.MustNewName("canvas-7e3a.local."), Port: DefaultAPIPort}),
+		resource(canvas, &dnsmessage.TXTResource{TXT: []string{"id=AA:BB:CC:DD:EE:FF", "md=NL29", "srcvers=4.1.3"}}),
+		resource("canvas-7e3a.local.", &dnsmessage.AResource{A: [4]byte{192, 168, 1, 20}}),
+		resource("canvas-7e3a.local.", &dnsmessage.AAAAResource{AAAA: [16]byte{0xfd, 0x00, 15: 0x20}}),
+		resource(MDNSService, &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName(shapes)}),
+		resource(shapes, &dnsmessage.SRVResource{Target: dnsmessage.MustNewName("shapes-ac09.local."), Port: DefaultAPIPort}),
+		resource(shapes, &dnsmessage.TXTResource{TXT: []string{"md=NL42"}}),
+		resource("shapes-ac09.local.", &dnsmessage.AAAAResource{AAAA: [16]byte{0xfd, 0x00, 15: 0x21}}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	controllers, err := (&MDNSDiscoverer{Address: address}).Discover(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []DiscoveredController{
+		{
+			Name:     "Canvas 7E3A",
+			Host:     "192.168.1.20",
+			Port:     DefaultAPIPort,
+			Model:    "NL29",
+			DeviceID: "AA:BB:CC:DD:EE:FF",
+			Firmware: "4.1.3",
+			Source:   DiscoverySourceMDNS,
+		},
+		{
+			Name:   "Shapes AC09",
+			Host:   "fd00::21",
+			Port:   DefaultAPIPort,
+			Model:  "NL42",
+			Source: DiscoverySourceMDNS,
+		},
+	}
+
+	if len(controllers) != len(want) {
+		t.Fatalf("got %d controllers, want %d: %+v", len(controllers), len(want), controllers)
+	}
+
+	for i := range want {
+		if controllers[i] != want[i] {
+			t.Errorf("got %+v, want %+v", controllers[i], want[i])
+		}
+	}
+
+	if url := controllers[1].URL(); url != "http://[fd00::21]:16021/api/v1" {
+		t.Errorf("got url %q", url)
+	}
+}
+
+func TestMDNSDiscoverWithoutAnswers(t *testing.T) {
+	address := mdnsResponder(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	controllers, err := (&MDNSDiscoverer{Address: address}).Discover(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(controllers) != 0 {
+		t.Errorf("got %+v, want no controllers", controllers)
+	}
+}
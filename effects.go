@@ -14,11 +14,16 @@ type NanoEffects struct {
 
 // EffectData effects data
 type EffectData struct {
-	Loop    bool   `json:"loop"`
-	Name    string `json:"animName"`
-	Type    string `json:"animType"`
-	Version string `json:"version"`
-	Data    string `json:"animData"`
+	Loop          bool           `json:"loop"`
+	Name          string         `json:"animName"`
+	Type          string         `json:"animType"`
+	Version       string         `json:"version"`
+	Data          string         `json:"animData"`
+	ColorType     string         `json:"colorType"`
+	Palette       Palette        `json:"palette"`
+	PluginUUID    string         `json:"pluginUuid"`
+	PluginType    string         `json:"pluginType"`
+	PluginOptions []PluginOption `json:"pluginOptions"`
 }
 
 // newNanoEffects returns a new NanoEffects instance
@@ -108,31 +113,13 @@ func (e *NanoEffects) Get() (string, error) {
 // GetEffectData returns data of the given effect
 func (e *NanoEffects) GetEffectData(effect string) (EffectData, error) {
 	var data EffectData
-	body := jsonPayload{
-		"write": jsonPayload{
-			"command":  "request",
-			"animName": effect,
-		},
-	}
-	resp, err := e.nano.client.R().SetHeader("Content-Type", "application/json").SetBody(body).Put(e.endpoint)
+	body, err := e.Export(effect)
 
 	if err != nil {
 		return data, err
 	}
 
-	if resp.StatusCode() == http.StatusUnauthorized {
-		return data, ErrUnauthorized
-	}
-
-	if resp.StatusCode() == http.StatusNotFound {
-		return data, ErrEffectNotFound
-	}
-
-	if resp.StatusCode() != http.StatusOK {
-		return data, ErrUnexpectedResponse
-	}
-
-	if err := json.Unmarshal(resp.Body(), &data); err != nil {
+	if err := json.Unmarshal(body, &data); err != nil {
 		fmt.Println(err)
 		return data, ErrParsingJSON
 	}
@@ -187,3 +174,62 @@ func (e *NanoEffects) ToString(effect StreamEffect) string {
 
 	return data
 }
+
+// Display validates given palette effect and displays it without saving
+func (e *NanoEffects) Display(effect PaletteEffect) error {
+	if err := effect.Validate(); err != nil {
+		return err
+	}
+
+	return e.WriteRaw(effect.payload("display"))
+}
+
+// Add validates given palette effect and saves it on the nanoleafs
+func (e *NanoEffects) Add(effect PaletteEffect) error {
+	if err := effect.Validate(); err != nil {
+		return err
+	}
+
+	return e.WriteRaw(effect.payload("add"))
+}
+
+// Export returns the complete definition of the given effect as json, suitable for Import
+func (e *NanoEffects) Export(name string) ([]byte, error) {
+	body := jsonPayload{
+		"write": jsonPayload{
+			"command":  "request",
+			"animName": name,
+		},
+	}
+	resp, err := e.nano.client.R().SetHeader("Content-Type", "application/json").SetBody(body).Put(e.endpoint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode() == http.StatusUnauthorized {
+		return nil, ErrUnauthorized
+	}
+
+	if resp.StatusCode() == http.StatusNotFound {
+		return nil, ErrEffectNotFound
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return nil, ErrUnexpectedResponse
+	}
+
+	return resp.Body(), nil
+}
+
+// Import saves an effect definition as returned by Export
+func (e *NanoEffects) Import(data []byte) error {
+	var write jsonPayload
+
+	if err := json.Unmarshal(data, &write); err != nil {
+		return ErrParsingJSON
+	}
+
+	write["command"] = "add"
+	return e.WriteRaw(jsonPayload{"write": write})
+}
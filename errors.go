@@ -21,6 +21,78 @@ var (
 	// ErrEffectNotFound occurs if given effect was not found
 	ErrEffectNotFound = errors.New("Effect not Found")
 
-	// ErrInvalidVersion occurs if given extControl Version does not match v1
-	ErrInvalidVersion = errors.New("Invalid version given. Please use v1")
+	// ErrInvalidVersion occurs if given extControl Version does not match v1 or v2
+	ErrInvalidVersion = errors.New("Invalid version given. Please use v1 or v2")
+
+	// ErrInvalidAnimType occurs if given animType is not one of the palette based animTypes
+	ErrInvalidAnimType = errors.New("Invalid animType given")
+
+	// ErrInvalidPalette occurs if a palette is empty or contains values out of range
+	ErrInvalidPalette = errors.New("Invalid palette given")
+
+	// ErrInvalidTiming occurs if transTime or delayTime are missing or out of range
+	ErrInvalidTiming = errors.New("Invalid transTime or delayTime given")
+
+	// ErrInvalidBrightnessRange occurs if the brightnessRange of an effect is out of range or missing for highlight effects
+	ErrInvalidBrightnessRange = errors.New("Invalid brightnessRange given")
+
+	// ErrInvalidWindowSize occurs if a wheel effect has no positive windowSize
+	ErrInvalidWindowSize = errors.New("Invalid windowSize given")
+
+	// ErrInvalidFlowFactor occurs if a flow effect has no positive flowFactor
+	ErrInvalidFlowFactor = errors.New("Invalid flowFactor given")
+
+	// ErrInvalidExplodeFactor occurs if an explode effect has no positive explodeFactor
+	ErrInvalidExplodeFactor = errors.New("Invalid explodeFactor given")
+
+	// ErrInvalidDirection occurs if given direction is not supported by the animType
+	ErrInvalidDirection = errors.New("Invalid direction given")
+
+	// ErrPluginNotFound occurs if given plugin uuid is not known by the nanoleafs
+	ErrPluginNotFound = errors.New("Plugin not Found")
+
+	// ErrInvalidPluginOption occurs if a plugin option is unknown or its value does not match the option schema
+	ErrInvalidPluginOption = errors.New("Invalid plugin option given")
+
+	// ErrStreamNotConnected occurs if an effect is written before the udp connection has been established
+	ErrStreamNotConnected = errors.New("Stream is not connected. Please connect before writing effects")
+
+	// ErrPanelIDOutOfRange occurs if a panel id does not fit into the packet format of the extControl version
+	ErrPanelIDOutOfRange = errors.New("Panel id out of range for the extControl version")
+
+	// ErrStreamEffectTooLarge occurs if a stream effect has more panels or frames than the extControl version can carry
+	ErrStreamEffectTooLarge = errors.New("Too many panels or frames for the extControl version")
+
+	// ErrRendererRunning occurs if a renderer is started twice
+	ErrRendererRunning = errors.New("Renderer is already running")
+
+	// ErrPanelNotFound occurs if given panel id is not part of the layout
+	ErrPanelNotFound = errors.New("Panel not Found")
+
+	// ErrEmptyAnimation occurs if an animation does not contain any frames
+	ErrEmptyAnimation = errors.New("Animation does not contain any frames")
+
+	// ErrFrameOutOfRange occurs if a player seeks to a frame that does not exist
+	ErrFrameOutOfRange = errors.New("Frame out of range")
+
+	// ErrUnsupportedAudio occurs if audio data is not uncompressed 8, 16, 24 or 32 bit pcm
+	ErrUnsupportedAudio = errors.New("Unsupported audio format. Please use uncompressed PCM")
+
+	// ErrStreamInactive occurs if extControl is no longer the active effect
+	ErrStreamInactive = errors.New("extControl is not active anymore")
+
+	// ErrNoPath occurs if two panels are not connected
+	ErrNoPath = errors.New("Panels are not connected")
+
+	// ErrRhythmNotConnected occurs if no rhythm module is attached
+	ErrRhythmNotConnected = errors.New("No Rhythm module connected")
+
+	// ErrAuxNotAvailable occurs if the aux input is selected on a rhythm module without aux input
+	ErrAuxNotAvailable = errors.New("Rhythm module has no aux input")
+
+	// ErrInvalidRhythmMode occurs if given rhythmMode is neither microphone nor aux
+	ErrInvalidRhythmMode = errors.New("Invalid rhythmMode given")
+
+	// ErrEventStreamClosed occurs if the controller closed the event stream
+	ErrEventStreamClosed = errors.New("Event stream closed by the controller")
 )
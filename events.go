@@ -0,0 +1,207 @@
+package nanoleaf
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// EventType kind of events sent by the controller
+type EventType int
+
+// Event types
+const (
+	EventTypeState   EventType = 1
+	EventTypeLayout  EventType = 2
+	EventTypeEffects EventType = 3
+	EventTypeTouch   EventType = 4
+)
+
+// Attributes of state events
+const (
+	StateAttrOn         = 1
+	StateAttrBrightness = 2
+	StateAttrHue        = 3
+	StateAttrSaturation = 4
+	StateAttrColorTemp  = 5
+	StateAttrColorMode  = 6
+)
+
+// Attributes of layout events
+const (
+	LayoutAttrLayout            = 1
+	LayoutAttrGlobalOrientation = 2
+)
+
+// Attributes of effects events
+const (
+	EffectsAttrSelected = 1
+)
+
+// maximum size of a single event line, layout events contain the whole layout
+const maxEventSize = 1 << 20
+
+// String returns a readable name of the event type
+func (t EventType) String() string {
+	switch t {
+	case EventTypeState:
+		return "state"
+	case EventTypeLayout:
+		return "layout"
+	case EventTypeEffects:
+		return "effects"
+	case EventTypeTouch:
+		return "touch"
+	}
+
+	return "unknown"
+}
+
+// Event a single event sent by the controller. Attr and Value are set for state, layout and effects events,
+// PanelID and Gesture for touch events
+type Event struct {
+	Type    EventType   `json:"-"`
+	Attr    int         `json:"attr"`
+	Value   interface{} `json:"value"`
+	PanelID int         `json:"panelId"`
+	Gesture int         `json:"gesture"`
+}
+
+// NanoEvents server sent events of the controller
+type NanoEvents struct {
+	nano     *Nanoleaf
+	endpoint string
+}
+
+// newNanoEvents returns a new instance of NanoEvents
+func newNanoEvents(nano *Nanoleaf) *NanoEvents {
+	return &NanoEvents{
+		nano:     nano,
+		endpoint: fmt.Sprintf("%s/%s/events", nano.url, nano.token),
+	}
+}
+
+// Listen subscribes to the given event types (every type if none is given) and calls handle for every event.
+// It blocks until ctx is cancelled or the connection is lost
+func (e *NanoEvents) Listen(ctx context.Context, handle func(Event), types ...EventType) error {
+	if len(types) == 0 {
+		types = []EventType{EventTypeState, EventTypeLayout, EventTypeEffects, EventTypeTouch}
+	}
+
+	ids := make([]string, len(types))
+	for i, t := range types {
+		ids[i] = strconv.Itoa(int(t))
+	}
+
+	resp, err := e.nano.client.R().
+		SetContext(ctx).
+		SetDoNotParseResponse(true).
+		SetQueryParam("id", strings.Join(ids, ",")).
+		Get(e.endpoint)
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		return err
+	}
+
+	body := resp.RawBody()
+	defer body.Close()
+
+	if resp.StatusCode() == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return ErrUnexpectedResponse
+	}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 4096), maxEventSize)
+
+	var eventType EventType
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "id:"):
+			id, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "id:")))
+			if err != nil {
+				return ErrParsingJSON
+			}
+
+			eventType = EventType(id)
+		case strings.HasPrefix(line, "data:"):
+			var data struct {
+				Events []Event `json:"events"`
+			}
+
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data:")), &data); err != nil {
+				return ErrParsingJSON
+			}
+
+			for _, event := range data.Events {
+				event.Type = eventType
+				handle(event)
+			}
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return ErrEventStreamClosed
+}
+
+// ApplyEvent updates info from a state or effects event and reports if the event could be applied.
+// Layout and touch events are never applied, call GetControllerInfo again if the layout changed
+func (c *ControllerInfo) ApplyEvent(e Event) bool {
+	switch e.Type {
+	case EventTypeState:
+		switch value := e.Value.(type) {
+		case bool:
+			if e.Attr == StateAttrOn {
+				c.State.On.Value = value
+				return true
+			}
+		case string:
+			if e.Attr == StateAttrColorMode {
+				c.State.ColorMode = value
+				return true
+			}
+		case float64:
+			switch e.Attr {
+			case StateAttrBrightness:
+				c.State.Brightness.Value = int(value)
+			case StateAttrHue:
+				c.State.Hue.Value = int(value)
+			case StateAttrSaturation:
+				c.State.Sat.Value = int(value)
+			case StateAttrColorTemp:
+				c.State.Ct.Value = int(value)
+			default:
+				return false
+			}
+
+			return true
+		}
+	case EventTypeEffects:
+		if value, ok := e.Value.(string); ok && e.Attr == EffectsAttrSelected {
+			c.Effects.Active = value
+			return true
+		}
+	}
+
+	return false
+}
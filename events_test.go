@@ -0,0 +1,189 @@
+package nanoleaf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// serveEvents returns a client whose controller answers the event stream with handler
+func serveEvents(t *testing.T, handler func(w http.ResponseWriter, r *http.Request)) *Nanoleaf {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/token/events" {
+			http.NotFound(w, r)
+			return
+		}
+
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	n := NewNanoleaf(server.URL + "/api/v1")
+	n.SetToken("token")
+	return n
+}
+
+func TestListen(t *testing.T) {
+	var query string
+
+	n := serveEvents(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("id")
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "id: 1\ndata: {\"events\":[{\"attr\":2,\"value\":65},{\"attr\":1,\"value\":true}]}\n\n")
+		fmt.Fprint(w, "id: 3\ndata: {\"events\":[{\"attr\":1,\"value\":\"Northern Lights\"}]}\n\n")
+		fmt.Fprint(w, "id: 4\ndata: {\"events\":[{\"panelId\":7,\"gesture\":0}]}\n\n")
+	})
+
+	var events []Event
+	err := n.Events.Listen(context.Background(), func(e Event) {
+		events = append(events, e)
+	}, EventTypeState, EventTypeEffects, EventTypeTouch)
+
+	if err != ErrEventStreamClosed {
+		t.Fatalf("got %v, want %v", err, ErrEventStreamClosed)
+	}
+
+	if query != "1,3,4" {
+		t.Errorf("got id %q, want %q", query, "1,3,4")
+	}
+
+	want := []Event{
+		{Type: EventTypeState, Attr: StateAttrBrightness, Value: 65.0},
+		{Type: EventTypeState, Attr: StateAttrOn, Value: true},
+		{Type: EventTypeEffects, Attr: EffectsAttrSelected, Value: "Northern Lights"},
+		{Type: EventTypeTouch, PanelID: 7},
+	}
+
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("got %+v, want %+v", events, want)
+	}
+}
+
+func TestListenAllTypes(t *testing.T) {
+	var query string
+
+	n := serveEvents(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("id")
+	})
+
+	n.Events.Listen(context.Background(), func(Event) {})
+
+	if query != "1,2,3,4" {
+		t.Errorf("got id %q, want %q", query, "1,2,3,4")
+	}
+}
+
+func TestListenErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		code int
+		want error
+	}{
+		{name: "unauthorized", code: http.StatusUnauthorized, want: ErrUnauthorized},
+		{name: "unexpected status", code: http.StatusInternalServerError, want: ErrUnexpectedResponse},
+		{name: "invalid id", code: http.StatusOK, body: "id: state\n", want: ErrParsingJSON},
+		{name: "invalid data", code: http.StatusOK, body: "id: 1\ndata: {\"events\":\n", want: ErrParsingJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := serveEvents(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.code)
+				fmt.Fprint(w, tt.body)
+			})
+
+			if err := n.Events.Listen(context.Background(), func(Event) {}); err != tt.want {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestListenCancel(t *testing.T) {
+	n := serveEvents(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := n.Events.Listen(ctx, func(Event) {}); err != context.DeadlineExceeded {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestApplyEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   Event
+		applied bool
+		check   func(info ControllerInfo) bool
+	}{
+		{
+			name:    "on",
+			event:   Event{Type: EventTypeState, Attr: StateAttrOn, Value: true},
+			applied: true,
+			check:   func(info ControllerInfo) bool { return info.State.On.Value },
+		},
+		{
+			name:    "brightness",
+			event:   Event{Type: EventTypeState, Attr: StateAttrBrightness, Value: 42.0},
+			applied: true,
+			check:   func(info ControllerInfo) bool { return info.State.Brightness.Value == 42 },
+		},
+		{
+			name:    "color temperature",
+			event:   Event{Type: EventTypeState, Attr: StateAttrColorTemp, Value: 2700.0},
+			applied: true,
+			check:   func(info ControllerInfo) bool { return info.State.Ct.Value == 2700 },
+		},
+		{
+			name:    "color mode",
+			event:   Event{Type: EventTypeState, Attr: StateAttrColorMode, Value: "ct"},
+			applied: true,
+			check:   func(info ControllerInfo) bool { return info.State.ColorMode == "ct" },
+		},
+		{
+			name:    "effect",
+			event:   Event{Type: EventTypeEffects, Attr: EffectsAttrSelected, Value: "Forest"},
+			applied: true,
+			check:   func(info ControllerInfo) bool { return info.Effects.Active == "Forest" },
+		},
+		{
+			name:  "wrong value type",
+			event: Event{Type: EventTypeState, Attr: StateAttrOn, Value: 1.0},
+		},
+		{
+			name:  "layout",
+			event: Event{Type: EventTypeLayout, Attr: LayoutAttrGlobalOrientation, Value: 90.0},
+		},
+		{
+			name:  "touch",
+			event: Event{Type: EventTypeTouch, PanelID: 7},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var info ControllerInfo
+
+			if applied := info.ApplyEvent(tt.event); applied != tt.applied {
+				t.Fatalf("got applied %v, want %v", applied, tt.applied)
+			}
+
+			if tt.check != nil && !tt.check(info) {
+				t.Errorf("event not applied: %+v", info)
+			}
+		})
+	}
+}
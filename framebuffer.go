@@ -0,0 +1,188 @@
+package nanoleaf
+
+import (
+	"image/color"
+	"sync"
+)
+
+// DefaultTransition is the transition time (in 100ms) used for framebuffer updates
+const DefaultTransition = 1
+
+// Framebuffer holds the color of every panel and tracks which panels changed since the last flush
+type Framebuffer struct {
+	mu         sync.Mutex
+	ids        []int
+	colors     map[int]color.RGBA
+	dirty      map[int]bool
+	transition int
+}
+
+// NewFramebuffer returns a framebuffer containing every light emitting panel of layout, all set to black
+func NewFramebuffer(layout *PanelLayout) *Framebuffer {
+	f := &Framebuffer{
+		colors:     map[int]color.RGBA{},
+		dirty:      map[int]bool{},
+		transition: DefaultTransition,
+	}
+
+	for _, panel := range layout.PositionData {
+		if panel.ShapeType.IsControllerUnit() {
+			continue
+		}
+
+		if _, ok := f.colors[panel.ID]; ok {
+			continue
+		}
+
+		f.ids = append(f.ids, panel.ID)
+		f.colors[panel.ID] = color.RGBA{A: 0xff}
+	}
+
+	return f
+}
+
+// NewFramebuffer fetches the current layout and returns a framebuffer for it
+func (l *NanoLayout) NewFramebuffer() (*Framebuffer, error) {
+	layout, err := l.GetLayout()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewFramebuffer(layout), nil
+}
+
+// Panels returns the ids of all panels in layout order
+func (f *Framebuffer) Panels() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := make([]int, len(f.ids))
+	copy(ids, f.ids)
+	return ids
+}
+
+// Set sets the color of a panel. Setting the color it already has does not mark it as changed
+func (f *Framebuffer) Set(id int, c color.Color) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	current, ok := f.colors[id]
+	if !ok {
+		return ErrPanelNotFound
+	}
+
+	next := toRGBA(c)
+	if next != current {
+		f.colors[id] = next
+		f.dirty[id] = true
+	}
+
+	return nil
+}
+
+// Get returns the color of a panel
+func (f *Framebuffer) Get(id int) (color.RGBA, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, ok := f.colors[id]
+	return c, ok
+}
+
+// Fill sets every panel to the given color
+func (f *Framebuffer) Fill(c color.Color) {
+	for _, id := range f.Panels() {
+		f.Set(id, c)
+	}
+}
+
+// SetTransition sets the transition time (in 100ms) sent with every update
+func (f *Framebuffer) SetTransition(transition int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.transition = transition
+}
+
+// Refresh marks every panel as changed so the next flush sends all of them
+func (f *Framebuffer) Refresh() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, id := range f.ids {
+		f.dirty[id] = true
+	}
+}
+
+// Flush returns the panels changed since the last flush (or every panel if full is set) and resets the changes
+func (f *Framebuffer) Flush(full bool) StreamEffect {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	effect := StreamEffect{Panels: []PanelEffect{}}
+
+	for _, id := range f.ids {
+		if !full && !f.dirty[id] {
+			continue
+		}
+
+		c := f.colors[id]
+		effect.Panels = append(effect.Panels, PanelEffect{
+			ID: id,
+			Frames: []FrameEffect{
+				{Red: int(c.R), Green: int(c.G), Blue: int(c.B), Transition: f.transition},
+			},
+		})
+	}
+
+	f.dirty = map[int]bool{}
+	return effect
+}
+
+// markDirty marks the given panels as changed again, used if sending them failed
+func (f *Framebuffer) markDirty(ids ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, id := range ids {
+		if _, ok := f.colors[id]; ok {
+			f.dirty[id] = true
+		}
+	}
+}
+
+// WriteFramebuffer writes the changed panels of fb (or every panel if full is set).
+// If the write fails the panels stay marked as changed
+func (s *NanoStream) WriteFramebuffer(fb *Framebuffer, full bool) error {
+	effect := fb.Flush(full)
+
+	if err := s.WriteEffect(effect); err != nil {
+		fb.markDirty(panelIDs(effect)...)
+		return err
+	}
+
+	return nil
+}
+
+// SubmitFramebuffer queues the changed panels of fb (or every panel if full is set) for the next tick.
+// Panels the renderer fails to send or drops on Stop are marked as changed again
+func (r *Renderer) SubmitFramebuffer(fb *Framebuffer, full bool) {
+	r.submit(fb.Flush(full), fb)
+}
+
+// panelIDs returns the ids of every panel of effect
+func panelIDs(effect StreamEffect) []int {
+	ids := make([]int, len(effect.Panels))
+	for i, panel := range effect.Panels {
+		ids[i] = panel.ID
+	}
+
+	return ids
+}
+
+// toRGBA converts any color into an opaque color.RGBA
+func toRGBA(c color.Color) color.RGBA {
+	r, g, b, _ := c.RGBA()
+	return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0xff}
+}
@@ -0,0 +1,21 @@
+// Package gateway serves a simplified json api for one or more nanoleaf controllers.
+//
+// The gateway pairs controllers itself and keeps their tokens in a Store, clients only need one of the
+// api keys of the gateway, sent as "Authorization: Bearer <key>" or "X-API-Key: <key>".
+//
+//	GET    /discover                controllers found via mDNS and SSDP, a Warning header reports a failed method
+//	GET    /devices                 every device as {"id", "url"}
+//	POST   /devices                 pair a device: {"id": "living-room", "url": "http://192.168.1.2:16021/api/v1"}
+//	                                adds the device as is if "token" is given as well
+//	GET    /devices/{id}            device including name, model, serial and firmware
+//	DELETE /devices/{id}            remove a device, ?unpair=true invalidates its token as well
+//	GET    /devices/{id}/state      {"on", "brightness", "hue", "sat", "ct", "colorMode", "effect"}
+//	PUT    /devices/{id}/state      {"on", "brightness", "duration", "hue", "sat", "ct"}, every field is optional
+//	GET    /devices/{id}/effects    {"select": "Flames", "effects": [...]}
+//	PUT    /devices/{id}/effects    {"select": "Flames"}
+//	GET    /devices/{id}/layout     {"globalOrientation", "layout"}
+//
+// Changes answer with 204 No Content, POST /devices with 201 Created. Errors are sent as {"error": "..."}
+// using 400 for invalid input, 401 for a missing api key, 404 for unknown devices or effects, 409 for
+// existing devices or controllers not in pairing mode and 502 if the controller failed.
+package gateway
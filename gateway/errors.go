@@ -0,0 +1,32 @@
+package gateway
+
+import "errors"
+
+var (
+	// ErrDeviceNotFound occurs if no device with the given id is known
+	ErrDeviceNotFound = errors.New("Device not Found")
+
+	// ErrDeviceExists occurs if a device with the given id has already been added
+	ErrDeviceExists = errors.New("Device already exists")
+
+	// ErrInvalidDeviceID occurs if a device id is empty or contains characters other than letters, digits, '.', '_' and '-'
+	ErrInvalidDeviceID = errors.New("Invalid device id given")
+
+	// ErrInvalidURL occurs if a device url is not an absolute http url
+	ErrInvalidURL = errors.New("Invalid url given. Please use the api url, e.g. http://192.168.1.2:16021/api/v1")
+
+	// ErrInvalidState occurs if a state change contains values out of range
+	ErrInvalidState = errors.New("Invalid state given")
+
+	// ErrNoAPIKeys occurs if a gateway is created without any api key
+	ErrNoAPIKeys = errors.New("At least one api key is required")
+
+	// ErrInvalidAPIKey occurs if a request carries a missing or unknown api key
+	ErrInvalidAPIKey = errors.New("Missing or invalid api key")
+
+	// ErrNoEffectSelected occurs if an effect change does not name an effect
+	ErrNoEffectSelected = errors.New("No effect selected")
+
+	// ErrInvalidBody occurs if a request body is not valid json or contains unknown fields
+	ErrInvalidBody = errors.New("Invalid request body")
+)
@@ -0,0 +1,237 @@
+package gateway
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/adnanbrq/nanoleaf"
+)
+
+// validID allowed device ids, they are used as path segments
+var validID = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Gateway serves a token free json api for several controllers. Pairing happens server side, the tokens
+// are kept in the store while clients authenticate with api keys of the gateway
+type Gateway struct {
+	mu      sync.RWMutex
+	store   Store
+	keys    []string
+	devices map[string]Device
+	clients map[string]*nanoleaf.Nanoleaf
+}
+
+// New returns a gateway serving the devices of store to requests carrying one of keys. Empty keys are ignored
+func New(store Store, keys ...string) (*Gateway, error) {
+	var valid []string
+	for _, key := range keys {
+		if key != "" {
+			valid = append(valid, key)
+		}
+	}
+
+	if len(valid) == 0 {
+		return nil, ErrNoAPIKeys
+	}
+
+	devices, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	g := &Gateway{
+		store:   store,
+		keys:    valid,
+		devices: map[string]Device{},
+		clients: map[string]*nanoleaf.Nanoleaf{},
+	}
+
+	for _, device := range devices {
+		g.add(device)
+	}
+
+	return g, nil
+}
+
+// GenerateKey returns a random api key
+func GenerateKey() (string, error) {
+	key := make([]byte, 24)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(key), nil
+}
+
+// Devices returns every device sorted by id
+func (g *Gateway) Devices() []Device {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	devices := make([]Device, 0, len(g.devices))
+	for _, device := range g.devices {
+		devices = append(devices, device)
+	}
+
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+	return devices
+}
+
+// Client returns the authorized client of the device with the given id
+func (g *Gateway) Client(id string) (*nanoleaf.Nanoleaf, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	client, ok := g.clients[id]
+	if !ok {
+		return nil, ErrDeviceNotFound
+	}
+
+	return client, nil
+}
+
+// Add adds an already paired device and persists it
+func (g *Gateway) Add(device Device) error {
+	if err := validate(device.ID, device.URL); err != nil {
+		return err
+	}
+
+	device.URL = strings.TrimSuffix(device.URL, "/")
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.devices[device.ID]; ok {
+		return ErrDeviceExists
+	}
+
+	g.add(device)
+
+	if err := g.save(); err != nil {
+		g.remove(device.ID)
+		return err
+	}
+
+	return nil
+}
+
+// Pair creates a token on the controller at url and adds it as device id. The pairing mode of the
+// controller has to be enabled by holding its power button for 5-7 seconds first
+func (g *Gateway) Pair(id, url string) (Device, error) {
+	if err := validate(id, url); err != nil {
+		return Device{}, err
+	}
+
+	url = strings.TrimSuffix(url, "/")
+
+	g.mu.RLock()
+	_, exists := g.devices[id]
+	g.mu.RUnlock()
+
+	if exists {
+		return Device{}, ErrDeviceExists
+	}
+
+	client := nanoleaf.NewNanoleaf(url)
+	if err := client.Auth.Authenticate(); err != nil {
+		return Device{}, err
+	}
+
+	device := Device{ID: id, URL: url, Token: client.GetToken()}
+	if err := g.Add(device); err != nil {
+		// the token would be unusable, so it is removed from the controller again
+		client.Auth.Unauthenticate()
+		return Device{}, err
+	}
+
+	return device, nil
+}
+
+// Remove removes the device of the given id. If unpair is set, its token is invalidated on the controller first
+func (g *Gateway) Remove(id string, unpair bool) error {
+	client, err := g.Client(id)
+	if err != nil {
+		return err
+	}
+
+	if unpair {
+		if err := client.Auth.Unauthenticate(); err != nil && err != nanoleaf.ErrUnauthorized {
+			return err
+		}
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	device, ok := g.devices[id]
+	if !ok {
+		return ErrDeviceNotFound
+	}
+
+	g.remove(id)
+
+	if err := g.save(); err != nil {
+		g.add(device)
+		return err
+	}
+
+	return nil
+}
+
+// authorized checks key against the api keys in constant time
+func (g *Gateway) authorized(key string) bool {
+	ok := false
+
+	for _, k := range g.keys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			ok = true
+		}
+	}
+
+	return ok
+}
+
+// add registers device and its client, the caller holds the lock
+func (g *Gateway) add(device Device) {
+	client := nanoleaf.NewNanoleaf(device.URL)
+	client.SetToken(device.Token)
+
+	g.devices[device.ID] = device
+	g.clients[device.ID] = client
+}
+
+// remove unregisters device and its client, the caller holds the lock
+func (g *Gateway) remove(id string) {
+	delete(g.devices, id)
+	delete(g.clients, id)
+}
+
+// save persists all devices, the caller holds the lock
+func (g *Gateway) save() error {
+	devices := make([]Device, 0, len(g.devices))
+	for _, device := range g.devices {
+		devices = append(devices, device)
+	}
+
+	sort.Slice(devices, func(i, j int) bool { return devices[i].ID < devices[j].ID })
+	return g.store.Save(devices)
+}
+
+// validate checks device id and url
+func validate(id, rawURL string) error {
+	if !validID.MatchString(id) {
+		return ErrInvalidDeviceID
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+
+	return nil
+}
@@ -0,0 +1,290 @@
+package gateway
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/adnanbrq/nanoleaf"
+)
+
+// pairedToken token handed out by the fake controller
+const pairedToken = "paired-token"
+
+// errStore occurs on every save of failStore
+var errStore = errors.New("disk full")
+
+// failStore loads no devices and fails every save
+type failStore struct{}
+
+// Load returns no devices
+func (failStore) Load() ([]Device, error) { return nil, nil }
+
+// Save always fails
+func (failStore) Save([]Device) error { return errStore }
+
+// controller fake nanoleaf controller recording every request
+type controller struct {
+	server   *httptest.Server
+	info     []byte
+	pairable bool
+
+	mu       sync.Mutex
+	requests []string
+}
+
+// newController returns a controller in pairing mode answering GET with testdata/canvas.json
+func newController(t *testing.T) *controller {
+	t.Helper()
+
+	info, err := ioutil.ReadFile(filepath.Join("..", "testdata", "canvas.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &controller{info: info, pairable: true}
+	c.server = httptest.NewServer(http.HandlerFunc(c.serve))
+	t.Cleanup(c.server.Close)
+
+	return c
+}
+
+// url returns the api url of the controller
+func (c *controller) url() string {
+	return c.server.URL + "/api/v1"
+}
+
+// recorded returns every request as "METHOD path body"
+func (c *controller) recorded() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string(nil), c.requests...)
+}
+
+// serve implements the endpoints used by the gateway
+func (c *controller) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/")
+
+	c.mu.Lock()
+	c.requests = append(c.requests, strings.TrimSpace(r.Method+" "+path+" "+string(body)))
+	c.mu.Unlock()
+
+	if path == "new" && r.Method == http.MethodPost {
+		if !c.pairable {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		w.Write([]byte(`{"auth_token":"` + pairedToken + `"}`))
+		return
+	}
+
+	parts := strings.SplitN(path, "/", 2)
+	if parts[0] != pairedToken {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		w.Write(c.info)
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		w.WriteHeader(http.StatusNoContent)
+	case parts[1] == "effects" && strings.Contains(string(body), "Missing"):
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodPut:
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(&MemoryStore{}, "", ""); err != ErrNoAPIKeys {
+		t.Errorf("got %v, want %v", err, ErrNoAPIKeys)
+	}
+
+	store := &MemoryStore{}
+	store.Save([]Device{{ID: "desk", URL: "http://192.168.1.2:16021/api/v1", Token: "abc"}})
+
+	g, err := New(store, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := g.Client("desk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if client.GetToken() != "abc" {
+		t.Errorf("got token %q, want %q", client.GetToken(), "abc")
+	}
+
+	if _, err := g.Client("kitchen"); err != ErrDeviceNotFound {
+		t.Errorf("got %v, want %v", err, ErrDeviceNotFound)
+	}
+}
+
+func TestPair(t *testing.T) {
+	c := newController(t)
+	store := &MemoryStore{}
+
+	g, err := New(store, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	device, err := g.Pair("living-room", c.url()+"/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Device{ID: "living-room", URL: c.url(), Token: pairedToken}
+	if device != want {
+		t.Errorf("got %+v, want %+v", device, want)
+	}
+
+	if stored, _ := store.Load(); len(stored) != 1 || stored[0] != want {
+		t.Errorf("got stored %+v, want %+v", stored, want)
+	}
+
+	if _, err := g.Pair("living-room", c.url()); err != ErrDeviceExists {
+		t.Errorf("got %v, want %v", err, ErrDeviceExists)
+	}
+}
+
+func TestPairErrors(t *testing.T) {
+	c := newController(t)
+	c.pairable = false
+
+	g, err := New(&MemoryStore{}, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := g.Pair("living room", c.url()); err != ErrInvalidDeviceID {
+		t.Errorf("got %v, want %v", err, ErrInvalidDeviceID)
+	}
+
+	if _, err := g.Pair("living-room", "192.168.1.2:16021"); err != ErrInvalidURL {
+		t.Errorf("got %v, want %v", err, ErrInvalidURL)
+	}
+
+	if _, err := g.Pair("living-room", c.url()); err != nanoleaf.ErrAuthNotReady {
+		t.Errorf("got %v, want %v", err, nanoleaf.ErrAuthNotReady)
+	}
+
+	if len(g.Devices()) != 0 {
+		t.Errorf("got devices %+v", g.Devices())
+	}
+}
+
+func TestPairStoreFailure(t *testing.T) {
+	c := newController(t)
+
+	g, err := New(failStore{}, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := g.Pair("living-room", c.url()); err != errStore {
+		t.Fatalf("got %v, want %v", err, errStore)
+	}
+
+	if len(g.Devices()) != 0 {
+		t.Errorf("got devices %+v", g.Devices())
+	}
+
+	requests := c.recorded()
+	if len(requests) != 2 || requests[0] != "POST new" || requests[1] != "DELETE "+pairedToken {
+		t.Errorf("got requests %q, want the token to be removed again", requests)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	c := newController(t)
+	store := &MemoryStore{}
+
+	g, err := New(store, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"desk", "hall"} {
+		if err := g.Add(Device{ID: id, URL: c.url(), Token: pairedToken}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := g.Remove("desk", false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.Remove("hall", true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.Remove("hall", false); err != ErrDeviceNotFound {
+		t.Errorf("got %v, want %v", err, ErrDeviceNotFound)
+	}
+
+	if stored, _ := store.Load(); len(stored) != 0 {
+		t.Errorf("got stored %+v", stored)
+	}
+
+	if requests := c.recorded(); len(requests) != 1 || requests[0] != "DELETE "+pairedToken {
+		t.Errorf("got requests %q, want a single unpair", requests)
+	}
+}
+
+func TestAddStoreFailure(t *testing.T) {
+	g, err := New(failStore{}, "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.Add(Device{ID: "desk", URL: "http://192.168.1.2:16021/api/v1", Token: "abc"}); err != errStore {
+		t.Fatalf("got %v, want %v", err, errStore)
+	}
+
+	if _, err := g.Client("desk"); err != ErrDeviceNotFound {
+		t.Errorf("got %v, want %v", err, ErrDeviceNotFound)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gateway")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	store := NewFileStore(filepath.Join(dir, "nested", "devices.json"))
+
+	if devices, err := store.Load(); err != nil || len(devices) != 0 {
+		t.Fatalf("got %+v, %v for a missing file", devices, err)
+	}
+
+	want := []Device{{ID: "desk", URL: "http://192.168.1.2:16021/api/v1", Token: "abc"}}
+	if err := store.Save(want); err != nil {
+		t.Fatal(err)
+	}
+
+	devices, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(devices) != 1 || devices[0] != want[0] {
+		t.Errorf("got %+v, want %+v", devices, want)
+	}
+}
@@ -0,0 +1,435 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/adnanbrq/nanoleaf"
+)
+
+// maxBodySize limits request bodies
+const maxBodySize = 1 << 16
+
+// DeviceInfo a device as returned by the api, tokens are never exposed
+type DeviceInfo struct {
+	ID              string `json:"id"`
+	URL             string `json:"url"`
+	Name            string `json:"name,omitempty"`
+	Model           string `json:"model,omitempty"`
+	Serial          string `json:"serialNo,omitempty"`
+	FirmwareVersion string `json:"firmwareVersion,omitempty"`
+	HardwareVersion string `json:"hardwareVersion,omitempty"`
+}
+
+// PairRequest body of POST /devices. Without token the controller is paired, otherwise the token is used as is
+type PairRequest struct {
+	ID    string `json:"id"`
+	URL   string `json:"url"`
+	Token string `json:"token,omitempty"`
+}
+
+// State body of GET /devices/{id}/state
+type State struct {
+	On         bool   `json:"on"`
+	Brightness int    `json:"brightness"`
+	Hue        int    `json:"hue"`
+	Sat        int    `json:"sat"`
+	Ct         int    `json:"ct"`
+	ColorMode  string `json:"colorMode"`
+	Effect     string `json:"effect"`
+}
+
+// StateUpdate body of PUT /devices/{id}/state, only given values are changed
+type StateUpdate struct {
+	On         *bool `json:"on,omitempty"`
+	Brightness *int  `json:"brightness,omitempty"`
+	// Duration of the brightness transition in seconds, passed to SetBrightness as is
+	Duration int  `json:"duration,omitempty"`
+	Hue      *int `json:"hue,omitempty"`
+	Sat      *int `json:"sat,omitempty"`
+	Ct       *int `json:"ct,omitempty"`
+}
+
+// Effects body of GET /devices/{id}/effects
+type Effects struct {
+	Select  string   `json:"select"`
+	Effects []string `json:"effects"`
+}
+
+// EffectsUpdate body of PUT /devices/{id}/effects
+type EffectsUpdate struct {
+	Select string `json:"select"`
+}
+
+// Layout body of GET /devices/{id}/layout
+type Layout struct {
+	GlobalOrientation int                  `json:"globalOrientation"`
+	Layout            nanoleaf.PanelLayout `json:"layout"`
+}
+
+// Discovered body entry of GET /discover
+type Discovered struct {
+	nanoleaf.DiscoveredController
+	URL string `json:"url"`
+}
+
+// errorResponse body of every failed request
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// ServeHTTP implements http.Handler
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !g.authorized(apiKey(r)) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="nanoleaf"`)
+		writeError(w, ErrInvalidAPIKey)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case len(parts) == 1 && parts[0] == "discover":
+		route(w, r, map[string]http.HandlerFunc{http.MethodGet: g.handleDiscover})
+	case len(parts) == 1 && parts[0] == "devices":
+		route(w, r, map[string]http.HandlerFunc{http.MethodGet: g.handleListDevices, http.MethodPost: g.handlePair})
+	case len(parts) == 2 && parts[0] == "devices":
+		route(w, r, map[string]http.HandlerFunc{
+			http.MethodGet:    g.withClient(parts[1], g.handleGetDevice),
+			http.MethodDelete: func(w http.ResponseWriter, r *http.Request) { g.handleRemove(w, r, parts[1]) },
+		})
+	case len(parts) == 3 && parts[0] == "devices" && parts[2] == "state":
+		route(w, r, map[string]http.HandlerFunc{
+			http.MethodGet: g.withClient(parts[1], handleGetState),
+			http.MethodPut: g.withClient(parts[1], handlePutState),
+		})
+	case len(parts) == 3 && parts[0] == "devices" && parts[2] == "effects":
+		route(w, r, map[string]http.HandlerFunc{
+			http.MethodGet: g.withClient(parts[1], handleGetEffects),
+			http.MethodPut: g.withClient(parts[1], handlePutEffects),
+		})
+	case len(parts) == 3 && parts[0] == "devices" && parts[2] == "layout":
+		route(w, r, map[string]http.HandlerFunc{http.MethodGet: g.withClient(parts[1], handleGetLayout)})
+	default:
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "Not Found"})
+	}
+}
+
+// handleDiscover lists controllers found on the local network
+func (g *Gateway) handleDiscover(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), nanoleaf.DefaultDiscoveryTimeout)
+	defer cancel()
+
+	controllers, err := nanoleaf.DiscoverAll(ctx)
+	if err != nil {
+		if len(controllers) == 0 {
+			writeError(w, err)
+			return
+		}
+
+		w.Header().Set("Warning", "199 - "+strconv.Quote(err.Error()))
+	}
+
+	discovered := make([]Discovered, len(controllers))
+	for i, c := range controllers {
+		discovered[i] = Discovered{DiscoveredController: c, URL: c.URL()}
+	}
+
+	writeJSON(w, http.StatusOK, discovered)
+}
+
+// handleListDevices lists every device
+func (g *Gateway) handleListDevices(w http.ResponseWriter, r *http.Request) {
+	devices := g.Devices()
+	infos := make([]DeviceInfo, len(devices))
+
+	for i, device := range devices {
+		infos[i] = DeviceInfo{ID: device.ID, URL: device.URL}
+	}
+
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// handlePair pairs or adds a device
+func (g *Gateway) handlePair(w http.ResponseWriter, r *http.Request) {
+	var req PairRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	device := Device{ID: req.ID, URL: strings.TrimSuffix(req.URL, "/"), Token: req.Token}
+
+	var err error
+	if req.Token == "" {
+		device, err = g.Pair(req.ID, device.URL)
+	} else {
+		err = g.Add(device)
+	}
+
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, DeviceInfo{ID: device.ID, URL: device.URL})
+}
+
+// handleRemove removes a device, ?unpair=true invalidates its token as well
+func (g *Gateway) handleRemove(w http.ResponseWriter, r *http.Request, id string) {
+	if err := g.Remove(id, r.URL.Query().Get("unpair") == "true"); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetDevice returns a device including its controller info
+func (g *Gateway) handleGetDevice(w http.ResponseWriter, r *http.Request, client *nanoleaf.Nanoleaf, device Device) {
+	info, err := client.GetControllerInfo()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, DeviceInfo{
+		ID:              device.ID,
+		URL:             device.URL,
+		Name:            info.Name,
+		Model:           info.Model,
+		Serial:          info.Serial,
+		FirmwareVersion: info.FirmwareVersion,
+		HardwareVersion: info.HardwareVersion,
+	})
+}
+
+// handleGetState returns the state of a device
+func handleGetState(w http.ResponseWriter, r *http.Request, client *nanoleaf.Nanoleaf, device Device) {
+	info, err := client.GetControllerInfo()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	s := info.State
+	writeJSON(w, http.StatusOK, State{
+		On:         s.On.Value,
+		Brightness: s.Brightness.Value,
+		Hue:        s.Hue.Value,
+		Sat:        s.Sat.Value,
+		Ct:         s.Ct.Value,
+		ColorMode:  s.ColorMode,
+		Effect:     info.Effects.Active,
+	})
+}
+
+// handlePutState changes the state of a device
+func handlePutState(w http.ResponseWriter, r *http.Request, client *nanoleaf.Nanoleaf, device Device) {
+	var update StateUpdate
+	if err := readJSON(r, &update); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := update.Apply(client); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetEffects returns the active and all available effects of a device
+func handleGetEffects(w http.ResponseWriter, r *http.Request, client *nanoleaf.Nanoleaf, device Device) {
+	info, err := client.GetControllerInfo()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	effects := info.Effects.List
+	if effects == nil {
+		effects = []string{}
+	}
+
+	writeJSON(w, http.StatusOK, Effects{Select: info.Effects.Active, Effects: effects})
+}
+
+// handlePutEffects selects an effect of a device
+func handlePutEffects(w http.ResponseWriter, r *http.Request, client *nanoleaf.Nanoleaf, device Device) {
+	var update EffectsUpdate
+	if err := readJSON(r, &update); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if update.Select == "" {
+		writeError(w, ErrNoEffectSelected)
+		return
+	}
+
+	if err := client.Effects.Set(update.Select); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleGetLayout returns the panel layout of a device
+func handleGetLayout(w http.ResponseWriter, r *http.Request, client *nanoleaf.Nanoleaf, device Device) {
+	info, err := client.GetControllerInfo()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, Layout{
+		GlobalOrientation: info.PanelLayout.GlobalOrientation.Value,
+		Layout:            info.PanelLayout.Layout,
+	})
+}
+
+// Validate checks the ranges of every given value
+func (u StateUpdate) Validate() error {
+	inRange := func(v *int, min, max int) bool {
+		return v == nil || (*v >= min && *v <= max)
+	}
+
+	if !inRange(u.Brightness, 0, 100) || !inRange(u.Hue, 0, 359) || !inRange(u.Sat, 0, 100) || !inRange(u.Ct, 1200, 6500) {
+		return ErrInvalidState
+	}
+
+	if u.Duration < 0 || (u.Ct != nil && (u.Hue != nil || u.Sat != nil)) {
+		return ErrInvalidState
+	}
+
+	return nil
+}
+
+// Apply validates the update and sends it to client. Turning off happens last since changing the brightness
+// turns the panels on
+func (u StateUpdate) Apply(client *nanoleaf.Nanoleaf) error {
+	if err := u.Validate(); err != nil {
+		return err
+	}
+
+	if u.Hue != nil {
+		if err := client.State.SetHue(*u.Hue, false); err != nil {
+			return err
+		}
+	}
+
+	if u.Sat != nil {
+		if err := client.State.SetSaturation(*u.Sat, false); err != nil {
+			return err
+		}
+	}
+
+	if u.Ct != nil {
+		if err := client.State.SetColorTemp(*u.Ct, false); err != nil {
+			return err
+		}
+	}
+
+	if u.Brightness != nil {
+		if err := client.State.SetBrightness(*u.Brightness, u.Duration); err != nil {
+			return err
+		}
+	}
+
+	if u.On != nil {
+		return client.State.SetOn(*u.On)
+	}
+
+	return nil
+}
+
+// withClient resolves the device of id before calling handle
+func (g *Gateway) withClient(id string, handle func(http.ResponseWriter, *http.Request, *nanoleaf.Nanoleaf, Device)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		g.mu.RLock()
+		device, ok := g.devices[id]
+		client := g.clients[id]
+		g.mu.RUnlock()
+
+		if !ok {
+			writeError(w, ErrDeviceNotFound)
+			return
+		}
+
+		handle(w, r, client, device)
+	}
+}
+
+// route calls the handler registered for the request method or answers 405
+func route(w http.ResponseWriter, r *http.Request, handlers map[string]http.HandlerFunc) {
+	if handler, ok := handlers[r.Method]; ok {
+		handler(w, r)
+		return
+	}
+
+	allowed := make([]string, 0, len(handlers))
+	for method := range handlers {
+		allowed = append(allowed, method)
+	}
+
+	sort.Strings(allowed)
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "Method not allowed"})
+}
+
+// apiKey returns the key sent as bearer token or X-API-Key header
+func apiKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+
+	return r.Header.Get("X-API-Key")
+}
+
+// readJSON decodes the request body into v, unknown fields are rejected
+func readJSON(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(v); err != nil {
+		return ErrInvalidBody
+	}
+
+	return nil
+}
+
+// writeJSON writes v with the given status code
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers with the status code matching err
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, statusOf(err), errorResponse{Error: err.Error()})
+}
+
+// statusOf maps errors onto http status codes. Errors of the controller result in 502
+func statusOf(err error) int {
+	switch err {
+	case ErrInvalidAPIKey:
+		return http.StatusUnauthorized
+	case ErrDeviceNotFound, nanoleaf.ErrEffectNotFound:
+		return http.StatusNotFound
+	case ErrDeviceExists, nanoleaf.ErrAuthNotReady:
+		return http.StatusConflict
+	case ErrInvalidDeviceID, ErrInvalidURL, ErrInvalidState, ErrNoEffectSelected, ErrInvalidBody:
+		return http.StatusBadRequest
+	}
+
+	return http.StatusBadGateway
+}
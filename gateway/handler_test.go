@@ -0,0 +1,278 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// apiKeyForTests api key accepted by newTestGateway
+const apiKeyForTests = "secret"
+
+// newTestGateway returns a gateway with the device "canvas" paired to c
+func newTestGateway(t *testing.T, c *controller) *Gateway {
+	t.Helper()
+
+	g, err := New(&MemoryStore{}, apiKeyForTests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.Add(Device{ID: "canvas", URL: c.url(), Token: pairedToken}); err != nil {
+		t.Fatal(err)
+	}
+
+	return g
+}
+
+// do sends an authorized request to g and returns the recorded response
+func do(g *Gateway, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+apiKeyForTests)
+
+	w := httptest.NewRecorder()
+	g.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthorization(t *testing.T) {
+	g := newTestGateway(t, newController(t))
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		status int
+	}{
+		{name: "bearer", header: "Authorization", value: "Bearer " + apiKeyForTests, status: http.StatusOK},
+		{name: "api key header", header: "X-API-Key", value: apiKeyForTests, status: http.StatusOK},
+		{name: "wrong key", header: "Authorization", value: "Bearer guess", status: http.StatusUnauthorized},
+		{name: "basic auth", header: "Authorization", value: "Basic " + apiKeyForTests, status: http.StatusUnauthorized},
+		{name: "missing", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/devices", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+
+			w := httptest.NewRecorder()
+			g.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Errorf("got %d, want %d", w.Code, tt.status)
+			}
+
+			if tt.status == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("missing WWW-Authenticate header")
+			}
+		})
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	g := newTestGateway(t, newController(t))
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		allow  string
+	}{
+		{method: http.MethodGet, path: "/unknown", status: http.StatusNotFound},
+		{method: http.MethodGet, path: "/devices/kitchen/state", status: http.StatusNotFound},
+		{method: http.MethodPost, path: "/devices/canvas/state", status: http.StatusMethodNotAllowed, allow: "GET, PUT"},
+		{method: http.MethodPut, path: "/devices/canvas/layout", status: http.StatusMethodNotAllowed, allow: "GET"},
+	}
+
+	for _, tt := range tests {
+		w := do(g, tt.method, tt.path, "")
+
+		if w.Code != tt.status {
+			t.Errorf("%s %s: got %d, want %d", tt.method, tt.path, w.Code, tt.status)
+		}
+
+		if allow := w.Header().Get("Allow"); allow != tt.allow {
+			t.Errorf("%s %s: got Allow %q, want %q", tt.method, tt.path, allow, tt.allow)
+		}
+	}
+}
+
+func TestDevices(t *testing.T) {
+	c := newController(t)
+	g := newTestGateway(t, c)
+
+	w := do(g, http.MethodPost, "/devices", `{"id":"hall","url":"`+c.url()+`"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("pair: got %d: %s", w.Code, w.Body)
+	}
+
+	if strings.Contains(w.Body.String(), pairedToken) {
+		t.Errorf("token exposed: %s", w.Body)
+	}
+
+	w = do(g, http.MethodPost, "/devices", `{"id":"hall","url":"`+c.url()+`"}`)
+	if w.Code != http.StatusConflict {
+		t.Errorf("pair again: got %d, want %d", w.Code, http.StatusConflict)
+	}
+
+	w = do(g, http.MethodPost, "/devices", `{"id":"desk","url":"`+c.url()+`","token":"abc"}`)
+	if w.Code != http.StatusCreated {
+		t.Errorf("add: got %d: %s", w.Code, w.Body)
+	}
+
+	var devices []DeviceInfo
+	w = do(g, http.MethodGet, "/devices", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &devices); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(devices) != 3 || devices[0].ID != "canvas" || devices[1].ID != "desk" || devices[2].ID != "hall" {
+		t.Errorf("got %+v", devices)
+	}
+
+	var device DeviceInfo
+	w = do(g, http.MethodGet, "/devices/canvas", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &device); err != nil {
+		t.Fatal(err)
+	}
+
+	want := DeviceInfo{ID: "canvas", URL: c.url(), Name: "Canvas 7E3A", Model: "NL29", Serial: "S19041C2731", FirmwareVersion: "4.1.3", HardwareVersion: "1.2-4"}
+	if device != want {
+		t.Errorf("got %+v, want %+v", device, want)
+	}
+
+	if w = do(g, http.MethodDelete, "/devices/hall?unpair=true", ""); w.Code != http.StatusNoContent {
+		t.Errorf("remove: got %d: %s", w.Code, w.Body)
+	}
+
+	if w = do(g, http.MethodGet, "/devices/hall", ""); w.Code != http.StatusNotFound {
+		t.Errorf("removed device: got %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestPairBadRequests(t *testing.T) {
+	g := newTestGateway(t, newController(t))
+
+	for _, body := range []string{
+		`{"id":"hall"`,
+		`{"id":"hall","url":"http://192.168.1.2:16021/api/v1","name":"Hall"}`,
+		`{"id":"../hall","url":"http://192.168.1.2:16021/api/v1","token":"abc"}`,
+		`{"id":"hall","url":"ftp://192.168.1.2/api/v1","token":"abc"}`,
+	} {
+		if w := do(g, http.MethodPost, "/devices", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want %d", body, w.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	c := newController(t)
+	g := newTestGateway(t, c)
+
+	var state State
+	w := do(g, http.MethodGet, "/devices/canvas/state", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &state); err != nil {
+		t.Fatal(err)
+	}
+
+	want := State{Brightness: 42, Hue: 210, Sat: 80, Ct: 2700, ColorMode: "hs", Effect: "*Solid*"}
+	if state != want {
+		t.Errorf("got %+v, want %+v", state, want)
+	}
+
+	w = do(g, http.MethodPut, "/devices/canvas/state", `{"brightness":60,"duration":2,"hue":359,"on":false}`)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("got %d: %s", w.Code, w.Body)
+	}
+
+	requests := c.recorded()
+	writes := requests[len(requests)-3:]
+	wantWrites := []string{
+		`PUT paired-token/state {"hue":{"value":359}}`,
+		`PUT paired-token/state {"brightness":{"duration":2,"value":60}}`,
+		`PUT paired-token/state {"on":{"value":false}}`,
+	}
+
+	for i := range wantWrites {
+		if writes[i] != wantWrites[i] {
+			t.Errorf("got %s, want %s", writes[i], wantWrites[i])
+		}
+	}
+}
+
+func TestStateValidation(t *testing.T) {
+	c := newController(t)
+	g := newTestGateway(t, c)
+
+	for _, body := range []string{
+		`{"brightness":101}`,
+		`{"brightness":-1}`,
+		`{"hue":360}`,
+		`{"sat":101}`,
+		`{"ct":1000}`,
+		`{"ct":2700,"hue":20}`,
+		`{"brightness":50,"duration":-1}`,
+		`{"brightness":"50"}`,
+	} {
+		if w := do(g, http.MethodPut, "/devices/canvas/state", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want %d", body, w.Code, http.StatusBadRequest)
+		}
+	}
+
+	if requests := c.recorded(); len(requests) != 0 {
+		t.Errorf("invalid states were sent: %q", requests)
+	}
+}
+
+func TestEffects(t *testing.T) {
+	c := newController(t)
+	g := newTestGateway(t, c)
+
+	var effects Effects
+	w := do(g, http.MethodGet, "/devices/canvas/effects", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &effects); err != nil {
+		t.Fatal(err)
+	}
+
+	if effects.Select != "*Solid*" || len(effects.Effects) != 4 {
+		t.Errorf("got %+v", effects)
+	}
+
+	tests := []struct {
+		body   string
+		status int
+	}{
+		{body: `{"select":"Prism"}`, status: http.StatusNoContent},
+		{body: `{"select":"Missing"}`, status: http.StatusNotFound},
+		{body: `{"select":""}`, status: http.StatusBadRequest},
+		{body: `{}`, status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		if w := do(g, http.MethodPut, "/devices/canvas/effects", tt.body); w.Code != tt.status {
+			t.Errorf("%s: got %d, want %d", tt.body, w.Code, tt.status)
+		}
+	}
+}
+
+func TestControllerErrors(t *testing.T) {
+	c := newController(t)
+
+	g, err := New(&MemoryStore{}, apiKeyForTests)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.Add(Device{ID: "canvas", URL: c.url(), Token: "revoked"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if w := do(g, http.MethodGet, "/devices/canvas/layout", ""); w.Code != http.StatusBadGateway {
+		t.Errorf("got %d, want %d", w.Code, http.StatusBadGateway)
+	}
+}
@@ -0,0 +1,101 @@
+package gateway
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Device a paired controller managed by the gateway
+type Device struct {
+	ID    string `json:"id"`
+	URL   string `json:"url"`
+	Token string `json:"token"`
+}
+
+// Store persists paired devices and their tokens
+type Store interface {
+	Load() ([]Device, error)
+	Save(devices []Device) error
+}
+
+// FileStore stores devices as json in a file only readable by the current user
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileStore returns a store writing to path, the file is created on the first save
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load returns the stored devices, a missing file results in no devices
+func (s *FileStore) Load() ([]Device, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []Device
+	if err := json.Unmarshal(data, &devices); err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+// Save replaces the stored devices. The file is written to a temporary file first and renamed afterwards
+// so a crash never leaves a truncated store behind
+func (s *FileStore) Save(devices []Device) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(devices, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+// MemoryStore keeps devices in memory only
+type MemoryStore struct {
+	mu      sync.Mutex
+	devices []Device
+}
+
+// Load returns the stored devices
+func (s *MemoryStore) Load() ([]Device, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Device(nil), s.devices...), nil
+}
+
+// Save replaces the stored devices
+func (s *MemoryStore) Save(devices []Device) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.devices = append([]Device(nil), devices...)
+	return nil
+}
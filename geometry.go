@@ -0,0 +1,294 @@
+package nanoleaf
+
+import "math"
+
+// ShapeType shapeType of a panel as reported in the layout
+type ShapeType int
+
+// Shape types known by the api
+const (
+	ShapeTriangle              ShapeType = 0
+	ShapeRhythm                ShapeType = 1
+	ShapeSquare                ShapeType = 2
+	ShapeControlSquareMaster   ShapeType = 3
+	ShapeControlSquarePassive  ShapeType = 4
+	ShapeHexagon               ShapeType = 7
+	ShapeTriangleShapes        ShapeType = 8
+	ShapeMiniTriangle          ShapeType = 9
+	ShapeShapesController      ShapeType = 12
+	ShapeElementsHexagon       ShapeType = 14
+	ShapeElementsHexagonCorner ShapeType = 15
+	ShapeLinesConnector        ShapeType = 16
+	ShapeLightLines            ShapeType = 17
+	ShapeLightLinesSingleZone  ShapeType = 18
+	ShapeControllerCap         ShapeType = 19
+	ShapePowerConnector        ShapeType = 20
+)
+
+// shapeInfo describes the polygon of a shape type
+type shapeInfo struct {
+	name       string
+	sides      int
+	sideLength float64
+	controller bool
+}
+
+// shapes known shape types. Elements corner hexagons are approximated as regular hexagons
+var shapes = map[ShapeType]shapeInfo{
+	ShapeTriangle:              {"Triangle", 3, 150, false},
+	ShapeRhythm:                {"Rhythm", 0, 0, true},
+	ShapeSquare:                {"Square", 4, 100, false},
+	ShapeControlSquareMaster:   {"Control Square Master", 4, 100, false},
+	ShapeControlSquarePassive:  {"Control Square Passive", 4, 100, false},
+	ShapeHexagon:               {"Hexagon", 6, 67, false},
+	ShapeTriangleShapes:        {"Triangle (Shapes)", 3, 134, false},
+	ShapeMiniTriangle:          {"Mini Triangle", 3, 67, false},
+	ShapeShapesController:      {"Shapes Controller", 0, 0, true},
+	ShapeElementsHexagon:       {"Elements Hexagon", 6, 134, false},
+	ShapeElementsHexagonCorner: {"Elements Hexagon Corner", 6, 58, false},
+	ShapeLinesConnector:        {"Lines Connector", 0, 0, true},
+	ShapeLightLines:            {"Light Lines", 2, 154, false},
+	ShapeLightLinesSingleZone:  {"Light Lines Single Zone", 2, 77, false},
+	ShapeControllerCap:         {"Controller Cap", 0, 0, true},
+	ShapePowerConnector:        {"Power Connector", 0, 0, true},
+}
+
+// String returns the name of the shape
+func (s ShapeType) String() string {
+	if info, ok := shapes[s]; ok {
+		return info.name
+	}
+
+	return "Unknown"
+}
+
+// Sides returns the number of polygon sides, 2 for lines and 0 for controller units
+func (s ShapeType) Sides() int {
+	return shapes[s].sides
+}
+
+// SideLength returns the side length of the shape in layout units
+func (s ShapeType) SideLength() float64 {
+	return shapes[s].sideLength
+}
+
+// IsControllerUnit checks if the shape is a controller, connector or module without light
+func (s ShapeType) IsControllerUnit() bool {
+	return shapes[s].controller
+}
+
+// Rect axis aligned rectangle in layout space
+type Rect struct {
+	Min Point `json:"min"`
+	Max Point `json:"max"`
+}
+
+// Width returns the width of the rectangle
+func (r Rect) Width() float64 {
+	return r.Max.X - r.Min.X
+}
+
+// Height returns the height of the rectangle
+func (r Rect) Height() float64 {
+	return r.Max.Y - r.Min.Y
+}
+
+// Center returns the center of the rectangle
+func (r Rect) Center() Point {
+	return Point{X: (r.Min.X + r.Max.X) / 2, Y: (r.Min.Y + r.Max.Y) / 2}
+}
+
+// Contains checks if p is inside the rectangle
+func (r Rect) Contains(p Point) bool {
+	return p.X >= r.Min.X && p.X <= r.Max.X && p.Y >= r.Min.Y && p.Y <= r.Max.Y
+}
+
+// Union returns the smallest rectangle containing r and o
+func (r Rect) Union(o Rect) Rect {
+	return Rect{
+		Min: Point{X: math.Min(r.Min.X, o.Min.X), Y: math.Min(r.Min.Y, o.Min.Y)},
+		Max: Point{X: math.Max(r.Max.X, o.Max.X), Y: math.Max(r.Max.Y, o.Max.Y)},
+	}
+}
+
+// PanelGeometry position, rotation and polygon of a single panel
+type PanelGeometry struct {
+	ID       int       `json:"panelId"`
+	Shape    ShapeType `json:"shapeType"`
+	Center   Point     `json:"center"`
+	Rotation float64   `json:"rotation"`
+	Vertices []Point   `json:"vertices"`
+}
+
+// LayoutGeometry geometry of every panel of a layout
+type LayoutGeometry struct {
+	Panels []PanelGeometry `json:"panels"`
+}
+
+// BoundingBox returns the bounding box of the panels polygon
+func (g PanelGeometry) BoundingBox() Rect {
+	if len(g.Vertices) == 0 {
+		return Rect{Min: g.Center, Max: g.Center}
+	}
+
+	r := Rect{Min: g.Vertices[0], Max: g.Vertices[0]}
+	for _, v := range g.Vertices[1:] {
+		r = r.Union(Rect{Min: v, Max: v})
+	}
+
+	return r
+}
+
+// Centroid returns the centroid of the panels polygon
+func (g PanelGeometry) Centroid() Point {
+	if len(g.Vertices) == 0 {
+		return g.Center
+	}
+
+	var c Point
+	for _, v := range g.Vertices {
+		c.X += v.X
+		c.Y += v.Y
+	}
+
+	return Point{X: c.X / float64(len(g.Vertices)), Y: c.Y / float64(len(g.Vertices))}
+}
+
+// Geometry returns the geometry of every panel rotated by the global orientation
+func (p *PanelLayout) Geometry(orientation int) LayoutGeometry {
+	positions := p.OrientedPositions(orientation)
+	geometry := LayoutGeometry{Panels: make([]PanelGeometry, 0, len(p.PositionData))}
+
+	for _, panel := range p.PositionData {
+		sideLength := panel.ShapeType.SideLength()
+
+		// old firmware only reports the side length of the whole layout
+		if panel.ShapeType == ShapeTriangle && p.SideLength > 0 {
+			sideLength = float64(p.SideLength)
+		}
+
+		rotation := math.Mod(float64(panel.O+orientation), 360)
+		center := positions[panel.ID]
+
+		geometry.Panels = append(geometry.Panels, PanelGeometry{
+			ID:       panel.ID,
+			Shape:    panel.ShapeType,
+			Center:   center,
+			Rotation: rotation,
+			Vertices: polygon(center, panel.ShapeType.Sides(), sideLength, rotation),
+		})
+	}
+
+	return geometry
+}
+
+// GetGeometry fetches layout and global orientation and returns the geometry of every panel
+func (l *NanoLayout) GetGeometry() (*LayoutGeometry, error) {
+	layout, err := l.GetLayout()
+
+	if err != nil {
+		return nil, err
+	}
+
+	orientation, err := l.GetGlobalOrientation()
+
+	if err != nil {
+		return nil, err
+	}
+
+	geometry := layout.Geometry(orientation.Value)
+	return &geometry, nil
+}
+
+// Panel returns the geometry of the given panel
+func (g LayoutGeometry) Panel(id int) (PanelGeometry, bool) {
+	for _, panel := range g.Panels {
+		if panel.ID == id {
+			return panel, true
+		}
+	}
+
+	return PanelGeometry{}, false
+}
+
+// BoundingBox returns the bounding box of all panels
+func (g LayoutGeometry) BoundingBox() Rect {
+	if len(g.Panels) == 0 {
+		return Rect{}
+	}
+
+	r := g.Panels[0].BoundingBox()
+	for _, panel := range g.Panels[1:] {
+		r = r.Union(panel.BoundingBox())
+	}
+
+	return r
+}
+
+// Centroid returns the average centroid of all light emitting panels
+func (g LayoutGeometry) Centroid() Point {
+	var c Point
+	n := 0
+
+	for _, panel := range g.Panels {
+		if panel.Shape.IsControllerUnit() {
+			continue
+		}
+
+		p := panel.Centroid()
+		c.X, c.Y, n = c.X+p.X, c.Y+p.Y, n+1
+	}
+
+	if n == 0 {
+		return c
+	}
+
+	return Point{X: c.X / float64(n), Y: c.Y / float64(n)}
+}
+
+// polygon returns the vertices of a regular polygon centered at center.
+// Triangles point up, squares are axis aligned and hexagons have flat tops at rotation 0.
+// Lines are returned as their two end points
+func polygon(center Point, sides int, sideLength, rotation float64) []Point {
+	switch {
+	case sides == 2:
+		a := rotatePoint(Point{X: center.X - sideLength/2, Y: center.Y}, center, rotation)
+		b := rotatePoint(Point{X: center.X + sideLength/2, Y: center.Y}, center, rotation)
+		return []Point{a, b}
+	case sides < 3 || sideLength <= 0:
+		return nil
+	}
+
+	radius := sideLength / (2 * math.Sin(math.Pi/float64(sides)))
+	start := 0.0
+
+	switch sides {
+	case 3:
+		start = 90
+	case 4:
+		start = 45
+	}
+
+	vertices := make([]Point, sides)
+	for i := range vertices {
+		angle := (start + rotation + float64(i)*360/float64(sides)) * math.Pi / 180
+		vertices[i] = Point{
+			X: center.X + radius*math.Cos(angle),
+			Y: center.Y + radius*math.Sin(angle),
+		}
+	}
+
+	return vertices
+}
+
+// Contains checks if p lies inside the panels polygon (or within lineWidth of a line)
+func (g PanelGeometry) Contains(p Point) bool {
+	switch len(g.Vertices) {
+	case 0:
+		return false
+	case 2:
+		return segmentDistance(p, g.Vertices[0], g.Vertices[1]) <= lineWidth/2
+	}
+
+	return insidePolygon(p, g.Vertices)
+}
@@ -2,4 +2,8 @@ module github.com/adnanbrq/nanoleaf
 
 go 1.14
 
-require github.com/go-resty/resty/v2 v2.2.0
+require (
+	github.com/eclipse/paho.mqtt.golang v1.2.0
+	github.com/go-resty/resty/v2 v2.2.0
+	golang.org/x/net v0.0.0-20200222125558-5a598a2470a0
+)
@@ -0,0 +1,248 @@
+package nanoleaf
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultAdjacencyTolerance is the distance (in layout units) below which panel edges are considered touching
+const DefaultAdjacencyTolerance = 5.0
+
+// PanelGraph neighbor graph of the panels of a layout
+type PanelGraph struct {
+	ids       []int
+	neighbors map[int][]int
+}
+
+// Graph builds the neighbor graph of the light emitting panels. Two panels are neighbors if they share
+// an edge within tolerance, lines are neighbors if their end points meet
+func (g LayoutGeometry) Graph(tolerance float64) *PanelGraph {
+	graph := &PanelGraph{neighbors: map[int][]int{}}
+	var panels []PanelGeometry
+
+	for _, panel := range g.Panels {
+		if panel.Shape.IsControllerUnit() || len(panel.Vertices) == 0 {
+			continue
+		}
+
+		if _, ok := graph.neighbors[panel.ID]; ok {
+			continue
+		}
+
+		panels = append(panels, panel)
+		graph.ids = append(graph.ids, panel.ID)
+		graph.neighbors[panel.ID] = []int{}
+	}
+
+	for i := range panels {
+		for j := i + 1; j < len(panels); j++ {
+			if touching(panels[i], panels[j], tolerance) {
+				a, b := panels[i].ID, panels[j].ID
+				graph.neighbors[a] = append(graph.neighbors[a], b)
+				graph.neighbors[b] = append(graph.neighbors[b], a)
+			}
+		}
+	}
+
+	sort.Ints(graph.ids)
+	for _, id := range graph.ids {
+		sort.Ints(graph.neighbors[id])
+	}
+
+	return graph
+}
+
+// GetGraph fetches the layout and returns the neighbor graph of its panels
+func (l *NanoLayout) GetGraph(tolerance float64) (*PanelGraph, error) {
+	geometry, err := l.GetGeometry()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return geometry.Graph(tolerance), nil
+}
+
+// Panels returns the ids of all panels in the graph in ascending order
+func (g *PanelGraph) Panels() []int {
+	ids := make([]int, len(g.ids))
+	copy(ids, g.ids)
+	return ids
+}
+
+// Neighbors returns the ids of the panels touching the given panel in ascending order
+func (g *PanelGraph) Neighbors(id int) []int {
+	neighbors := make([]int, len(g.neighbors[id]))
+	copy(neighbors, g.neighbors[id])
+	return neighbors
+}
+
+// Distances returns the number of hops from start to every reachable panel
+func (g *PanelGraph) Distances(start int) map[int]int {
+	distances := map[int]int{}
+
+	if _, ok := g.neighbors[start]; !ok {
+		return distances
+	}
+
+	distances[start] = 0
+	queue := []int{start}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, next := range g.neighbors[current] {
+			if _, seen := distances[next]; seen {
+				continue
+			}
+
+			distances[next] = distances[current] + 1
+			queue = append(queue, next)
+		}
+	}
+
+	return distances
+}
+
+// Rings returns the reachable panels grouped by their distance from start, e.g. for ripple effects
+func (g *PanelGraph) Rings(start int) [][]int {
+	var rings [][]int
+
+	distances := g.Distances(start)
+	for _, id := range g.ids {
+		d, ok := distances[id]
+		if !ok {
+			continue
+		}
+
+		for len(rings) <= d {
+			rings = append(rings, []int{})
+		}
+
+		rings[d] = append(rings[d], id)
+	}
+
+	return rings
+}
+
+// ShortestPath returns the panels on a shortest path from one panel to another including both ends
+func (g *PanelGraph) ShortestPath(from, to int) ([]int, error) {
+	if _, ok := g.neighbors[from]; !ok {
+		return nil, ErrPanelNotFound
+	}
+
+	if _, ok := g.neighbors[to]; !ok {
+		return nil, ErrPanelNotFound
+	}
+
+	previous := map[int]int{from: from}
+	queue := []int{from}
+
+	for len(queue) > 0 && queue[0] != to {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, next := range g.neighbors[current] {
+			if _, seen := previous[next]; seen {
+				continue
+			}
+
+			previous[next] = current
+			queue = append(queue, next)
+		}
+	}
+
+	if _, ok := previous[to]; !ok {
+		return nil, ErrNoPath
+	}
+
+	path := []int{to}
+	for current := to; current != from; {
+		current = previous[current]
+		path = append([]int{current}, path...)
+	}
+
+	return path, nil
+}
+
+// Components returns groups of connected panels, each sorted and ordered by their lowest id
+func (g *PanelGraph) Components() [][]int {
+	var components [][]int
+	seen := map[int]bool{}
+
+	for _, id := range g.ids {
+		if seen[id] {
+			continue
+		}
+
+		var component []int
+		for member := range g.Distances(id) {
+			seen[member] = true
+			component = append(component, member)
+		}
+
+		sort.Ints(component)
+		components = append(components, component)
+	}
+
+	return components
+}
+
+// touching checks if two panels share an edge (or an end point for lines)
+func touching(a, b PanelGeometry, tolerance float64) bool {
+	if len(a.Vertices) == 2 || len(b.Vertices) == 2 {
+		for _, p := range a.Vertices {
+			for _, q := range b.Vertices {
+				if distance(p, q) <= tolerance {
+					return true
+				}
+			}
+		}
+
+		return false
+	}
+
+	for i := range a.Vertices {
+		a1, a2 := a.Vertices[i], a.Vertices[(i+1)%len(a.Vertices)]
+
+		for j := range b.Vertices {
+			b1, b2 := b.Vertices[j], b.Vertices[(j+1)%len(b.Vertices)]
+
+			if sharedEdge(a1, a2, b1, b2, tolerance) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// sharedEdge checks if segment b lies on the line of segment a and both overlap by more than tolerance
+func sharedEdge(a1, a2, b1, b2 Point, tolerance float64) bool {
+	length := distance(a1, a2)
+	if length == 0 {
+		return false
+	}
+
+	dx, dy := (a2.X-a1.X)/length, (a2.Y-a1.Y)/length
+
+	// perpendicular distance of b's end points to the line through a
+	for _, p := range []Point{b1, b2} {
+		if math.Abs((p.X-a1.X)*dy-(p.Y-a1.Y)*dx) > tolerance {
+			return false
+		}
+	}
+
+	// overlap of the projections onto a
+	t1 := (b1.X-a1.X)*dx + (b1.Y-a1.Y)*dy
+	t2 := (b2.X-a1.X)*dx + (b2.Y-a1.Y)*dy
+	overlap := math.Min(length, math.Max(t1, t2)) - math.Max(0, math.Min(t1, t2))
+
+	return overlap > tolerance
+}
+
+// distance returns the euclidean distance of two points
+func distance(a, b Point) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
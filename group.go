@@ -0,0 +1,163 @@
+package nanoleaf
+
+import "sync"
+
+// DefaultGroupParallelism is used if a group is created without a valid parallelism
+const DefaultGroupParallelism = 4
+
+// GroupResult outcome of a command on a single controller of a group
+type GroupResult struct {
+	Client *Nanoleaf
+	Err    error
+}
+
+// GroupResults outcome of a command on every controller of a group, in the order of the group
+type GroupResults []GroupResult
+
+// Err returns the first error or nil if the command succeeded everywhere
+func (r GroupResults) Err() error {
+	for _, result := range r {
+		if result.Err != nil {
+			return result.Err
+		}
+	}
+
+	return nil
+}
+
+// Failed returns the results with an error
+func (r GroupResults) Failed() GroupResults {
+	failed := GroupResults{}
+
+	for _, result := range r {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	return failed
+}
+
+// Group runs commands on many controllers at once
+type Group struct {
+	mu          sync.Mutex
+	clients     []*Nanoleaf
+	parallelism int
+}
+
+// NewGroup returns a group running at most parallelism commands at the same time
+func NewGroup(parallelism int, clients ...*Nanoleaf) *Group {
+	if parallelism <= 0 {
+		parallelism = DefaultGroupParallelism
+	}
+
+	return &Group{
+		clients:     clients,
+		parallelism: parallelism,
+	}
+}
+
+// Add adds clients to the group
+func (g *Group) Add(clients ...*Nanoleaf) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.clients = append(g.clients, clients...)
+}
+
+// Clients returns the clients of the group
+func (g *Group) Clients() []*Nanoleaf {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	clients := make([]*Nanoleaf, len(g.clients))
+	copy(clients, g.clients)
+	return clients
+}
+
+// Do runs fn for every client of the group and waits for all of them.
+// Clients without a token fail with ErrUnauthorized without calling fn
+func (g *Group) Do(fn func(n *Nanoleaf) error) GroupResults {
+	clients := g.Clients()
+	results := make(GroupResults, len(clients))
+	slots := make(chan struct{}, g.parallelism)
+
+	var wg sync.WaitGroup
+	for i, client := range clients {
+		results[i].Client = client
+
+		if !client.IsConnected() {
+			results[i].Err = ErrUnauthorized
+			continue
+		}
+
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func(i int, client *Nanoleaf) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			results[i].Err = fn(client)
+		}(i, client)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// SetOn turns every controller on or off
+func (g *Group) SetOn(state bool) GroupResults {
+	return g.Do(func(n *Nanoleaf) error {
+		return n.State.SetOn(state)
+	})
+}
+
+// SetBrightness sets the brightness of every controller over given time (seconds)
+func (g *Group) SetBrightness(value, time int) GroupResults {
+	return g.Do(func(n *Nanoleaf) error {
+		return n.State.SetBrightness(value, time)
+	})
+}
+
+// SetHue sets the hue of every controller or increments it
+func (g *Group) SetHue(value int, isIncremental bool) GroupResults {
+	return g.Do(func(n *Nanoleaf) error {
+		return n.State.SetHue(value, isIncremental)
+	})
+}
+
+// SetSaturation sets the saturation of every controller or increments it
+func (g *Group) SetSaturation(value int, isIncremental bool) GroupResults {
+	return g.Do(func(n *Nanoleaf) error {
+		return n.State.SetSaturation(value, isIncremental)
+	})
+}
+
+// SetColorTemp sets the color temperature of every controller or increments it
+func (g *Group) SetColorTemp(value int, isIncremental bool) GroupResults {
+	return g.Do(func(n *Nanoleaf) error {
+		return n.State.SetColorTemp(value, isIncremental)
+	})
+}
+
+// SetEffect selects the given effect on every controller
+func (g *Group) SetEffect(name string) GroupResults {
+	return g.Do(func(n *Nanoleaf) error {
+		return n.Effects.Set(name)
+	})
+}
+
+// DisplayEffect displays the given palette effect on every controller
+func (g *Group) DisplayEffect(effect PaletteEffect) GroupResults {
+	return g.Do(func(n *Nanoleaf) error {
+		return n.Effects.Display(effect)
+	})
+}
+
+// Flash lets every controller flash
+func (g *Group) Flash() GroupResults {
+	return g.Do(func(n *Nanoleaf) error {
+		return n.Identity.Flash()
+	})
+}
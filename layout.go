@@ -3,6 +3,7 @@ package nanoleaf
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 )
 
@@ -17,10 +18,12 @@ type GlobalOrientation MinMaxValue
 
 // PanelPositionData positionData as a go struct
 type PanelPositionData struct {
-	ID int `json:"panelId"`
-	X  int `json:"x"`
-	Y  int `json:"y"`
-	Z  int `json:"z"`
+	ID        int       `json:"panelId"`
+	X         int       `json:"x"`
+	Y         int       `json:"y"`
+	Z         int       `json:"z"`
+	O         int       `json:"o"`
+	ShapeType ShapeType `json:"shapeType"`
 }
 
 // PanelLayout panelLayout as a go struct
@@ -30,6 +33,12 @@ type PanelLayout struct {
 	PositionData []PanelPositionData `json:"positionData"`
 }
 
+// Point is a position in layout space
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
 // newNanoLayout returns a new instance of NanoLayout
 func newNanoLayout(nano *Nanoleaf) *NanoLayout {
 	return &NanoLayout{
@@ -110,3 +119,38 @@ func (l *NanoLayout) GetLayout() (*PanelLayout, error) {
 
 	return &panelLayout, nil
 }
+
+// OrientedPositions returns the position of every panel rotated by orientation degrees around the layouts center
+func (p *PanelLayout) OrientedPositions(orientation int) map[int]Point {
+	positions := make(map[int]Point, len(p.PositionData))
+
+	if len(p.PositionData) == 0 {
+		return positions
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, panel := range p.PositionData {
+		minX, maxX = math.Min(minX, float64(panel.X)), math.Max(maxX, float64(panel.X))
+		minY, maxY = math.Min(minY, float64(panel.Y)), math.Max(maxY, float64(panel.Y))
+	}
+
+	center := Point{X: (minX + maxX) / 2, Y: (minY + maxY) / 2}
+	for _, panel := range p.PositionData {
+		positions[panel.ID] = rotatePoint(Point{X: float64(panel.X), Y: float64(panel.Y)}, center, float64(orientation))
+	}
+
+	return positions
+}
+
+// rotatePoint rotates p counter clockwise by deg degrees around center
+func rotatePoint(p, center Point, deg float64) Point {
+	rad := deg * math.Pi / 180
+	sin, cos := math.Sin(rad), math.Cos(rad)
+	dx, dy := p.X-center.X, p.Y-center.Y
+
+	return Point{
+		X: center.X + dx*cos - dy*sin,
+		Y: center.Y + dx*sin + dy*cos,
+	}
+}
@@ -0,0 +1,199 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultBuckets upper bounds of the request latency histogram in seconds
+var defaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// label a single name value pair
+type label struct {
+	name  string
+	value string
+}
+
+// labelSet formats labels as {a="b",c="d"}, values are escaped as required by the text format
+func labelSet(labels ...label) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = l.name + `="` + escape(l.value) + `"`
+	}
+
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// escape escapes backslashes, quotes and line feeds of label values
+func escape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// formatValue formats v as expected by the text format
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sample a single value of a metric family, suffix is appended to the family name (e.g. "_bucket")
+type sample struct {
+	suffix string
+	labels string
+	value  float64
+}
+
+// family a metric with its samples
+type family struct {
+	name    string
+	help    string
+	kind    string
+	samples []sample
+}
+
+// add appends a sample
+func (f *family) add(value float64, labels ...label) {
+	f.samples = append(f.samples, sample{labels: labelSet(labels...), value: value})
+}
+
+// write writes the family in the text format, families without samples are skipped
+func (f *family) write(w io.Writer) error {
+	if len(f.samples) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind); err != nil {
+		return err
+	}
+
+	for _, s := range f.samples {
+		if _, err := fmt.Fprintf(w, "%s%s%s %s\n", f.name, s.suffix, s.labels, formatValue(s.value)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// counterVec counters keyed by their label set
+type counterVec struct {
+	values map[string]float64
+	labels map[string][]label
+}
+
+// newCounterVec returns an empty counter vector
+func newCounterVec() *counterVec {
+	return &counterVec{values: map[string]float64{}, labels: map[string][]label{}}
+}
+
+// add increases the counter of labels by v
+func (c *counterVec) add(v float64, labels ...label) {
+	key := labelSet(labels...)
+	c.values[key] += v
+	c.labels[key] = labels
+}
+
+// collect appends every counter to f in a stable order
+func (c *counterVec) collect(f *family) {
+	for _, key := range sortedKeys(c.values) {
+		f.add(c.values[key], c.labels[key]...)
+	}
+}
+
+// histogram cumulative histogram of observations
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// observe adds v
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.buckets {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+
+	h.sum += v
+	h.count++
+}
+
+// histogramVec histograms keyed by their label set
+type histogramVec struct {
+	buckets    []float64
+	histograms map[string]*histogram
+	labels     map[string][]label
+}
+
+// newHistogramVec returns an empty histogram vector using buckets
+func newHistogramVec(buckets []float64) *histogramVec {
+	return &histogramVec{buckets: buckets, histograms: map[string]*histogram{}, labels: map[string][]label{}}
+}
+
+// observe adds v to the histogram of labels
+func (h *histogramVec) observe(v float64, labels ...label) {
+	key := labelSet(labels...)
+
+	hist, ok := h.histograms[key]
+	if !ok {
+		hist = &histogram{buckets: h.buckets, counts: make([]uint64, len(h.buckets))}
+		h.histograms[key] = hist
+		h.labels[key] = labels
+	}
+
+	hist.observe(v)
+}
+
+// collect appends buckets, sum and count of every histogram to f in a stable order
+func (h *histogramVec) collect(f *family) {
+	keys := make([]string, 0, len(h.histograms))
+	for key := range h.histograms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		hist, labels := h.histograms[key], h.labels[key]
+		bucket := func(le string) string {
+			return labelSet(append(labels[:len(labels):len(labels)], label{"le", le})...)
+		}
+
+		for i, bound := range hist.buckets {
+			f.samples = append(f.samples, sample{suffix: "_bucket", labels: bucket(formatValue(bound)), value: float64(hist.counts[i])})
+		}
+
+		f.samples = append(f.samples,
+			sample{suffix: "_bucket", labels: bucket("+Inf"), value: float64(hist.count)},
+			sample{suffix: "_sum", labels: key, value: hist.sum},
+			sample{suffix: "_count", labels: key, value: float64(hist.count)},
+		)
+	}
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,333 @@
+// Package metrics exports the state of nanoleaf controllers and the health of their clients in the
+// prometheus text format.
+//
+// State gauges are collected on every scrape using GetControllerInfo:
+//
+//	nanoleaf_up, nanoleaf_info, nanoleaf_on, nanoleaf_brightness, nanoleaf_hue, nanoleaf_saturation,
+//	nanoleaf_color_temperature_kelvin, nanoleaf_panels, nanoleaf_rhythm_connected, nanoleaf_rhythm_active,
+//	nanoleaf_scrape_duration_seconds
+//
+// Client metrics are recorded by observing every request and stream packet of the registered clients.
+// Requests failing without a response are counted as errors only:
+//
+//	nanoleaf_requests_total, nanoleaf_request_duration_seconds, nanoleaf_request_errors_total,
+//	nanoleaf_stream_packets_total, nanoleaf_stream_bytes_total, nanoleaf_stream_errors_total
+//
+// Every metric carries the name the controller was registered with as "controller" label.
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/adnanbrq/nanoleaf"
+)
+
+// DefaultScrapeTimeout is used if an exporter is created without a valid scrape timeout
+const DefaultScrapeTimeout = 5 * time.Second
+
+// contentType of the text format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Exporter collects metrics of every registered controller
+type Exporter struct {
+	timeout     time.Duration
+	mu          sync.Mutex
+	controllers map[string]*nanoleaf.Nanoleaf
+	requests    *counterVec
+	latency     *histogramVec
+	errors      *counterVec
+	packets     *counterVec
+	bytes       *counterVec
+	streamErrs  *counterVec
+}
+
+// scrapeResult outcome of collecting the state of a single controller
+type scrapeResult struct {
+	name     string
+	info     *nanoleaf.ControllerInfo
+	duration time.Duration
+}
+
+// observer records the client metrics of a single controller
+type observer struct {
+	e    *Exporter
+	name string
+}
+
+// NewExporter returns an exporter waiting at most timeout for every controller per scrape
+func NewExporter(timeout time.Duration) *Exporter {
+	if timeout <= 0 {
+		timeout = DefaultScrapeTimeout
+	}
+
+	return &Exporter{
+		timeout:     timeout,
+		controllers: map[string]*nanoleaf.Nanoleaf{},
+		requests:    newCounterVec(),
+		latency:     newHistogramVec(defaultBuckets),
+		errors:      newCounterVec(),
+		packets:     newCounterVec(),
+		bytes:       newCounterVec(),
+		streamErrs:  newCounterVec(),
+	}
+}
+
+// Register adds the controller of client as name and starts observing its requests and stream packets.
+// This replaces any observer set on client before
+func (e *Exporter) Register(name string, client *nanoleaf.Nanoleaf) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if old, ok := e.controllers[name]; ok && old != client {
+		old.SetObserver(nil)
+	}
+
+	e.controllers[name] = client
+	client.SetObserver(&observer{e: e, name: name})
+}
+
+// Unregister removes the controller registered as name, recorded client metrics are kept
+func (e *Exporter) Unregister(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if client, ok := e.controllers[name]; ok {
+		client.SetObserver(nil)
+		delete(e.controllers, name)
+	}
+}
+
+// ServeHTTP implements http.Handler serving every metric in the text format
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var b bytes.Buffer
+
+	if err := e.Write(r.Context(), &b); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(b.Bytes())
+}
+
+// Write scrapes every controller and writes all metrics in the text format
+func (e *Exporter) Write(ctx context.Context, w io.Writer) error {
+	results := e.scrape(ctx)
+
+	families := stateFamilies(results)
+
+	e.mu.Lock()
+	families = append(families, e.clientFamilies()...)
+	e.mu.Unlock()
+
+	for _, f := range families {
+		if err := f.write(w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scrape fetches the controller info of every controller concurrently. Controllers not answering
+// within the scrape timeout are reported as down
+func (e *Exporter) scrape(ctx context.Context) []scrapeResult {
+	e.mu.Lock()
+	names := make([]string, 0, len(e.controllers))
+	clients := make([]*nanoleaf.Nanoleaf, 0, len(e.controllers))
+	for name := range e.controllers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		clients = append(clients, e.controllers[name])
+	}
+	e.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	results := make([]scrapeResult, len(names))
+	var wg sync.WaitGroup
+
+	for i := range names {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			start := time.Now()
+			info, _ := clients[i].GetControllerInfoContext(ctx)
+
+			results[i].name = names[i]
+			results[i].info = info
+			results[i].duration = time.Since(start)
+		}(i)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// stateFamilies returns the state gauges of every scraped controller
+func stateFamilies(results []scrapeResult) []*family {
+	up := &family{name: "nanoleaf_up", help: "Whether the controller answered the last scrape", kind: "gauge"}
+	info := &family{name: "nanoleaf_info", help: "Information about the controller", kind: "gauge"}
+	on := &family{name: "nanoleaf_on", help: "Whether the panels are on", kind: "gauge"}
+	brightness := &family{name: "nanoleaf_brightness", help: "Brightness of the panels (0-100)", kind: "gauge"}
+	hue := &family{name: "nanoleaf_hue", help: "Hue of the panels in degrees", kind: "gauge"}
+	sat := &family{name: "nanoleaf_saturation", help: "Saturation of the panels (0-100)", kind: "gauge"}
+	ct := &family{name: "nanoleaf_color_temperature_kelvin", help: "Color temperature of the panels", kind: "gauge"}
+	panels := &family{name: "nanoleaf_panels", help: "Number of light emitting panels", kind: "gauge"}
+	rhythmConnected := &family{name: "nanoleaf_rhythm_connected", help: "Whether a rhythm module is connected", kind: "gauge"}
+	rhythmActive := &family{name: "nanoleaf_rhythm_active", help: "Whether the rhythm module drives the current effect", kind: "gauge"}
+	duration := &family{name: "nanoleaf_scrape_duration_seconds", help: "Time it took to fetch the state of the controller", kind: "gauge"}
+
+	for _, r := range results {
+		controller := label{"controller", r.name}
+		duration.add(r.duration.Seconds(), controller)
+
+		if r.info == nil {
+			up.add(0, controller)
+			continue
+		}
+
+		s := r.info.State
+		up.add(1, controller)
+		info.add(1, controller,
+			label{"name", r.info.Name},
+			label{"model", r.info.Model},
+			label{"serial", r.info.Serial},
+			label{"firmware_version", r.info.FirmwareVersion},
+			label{"color_mode", s.ColorMode},
+			label{"effect", r.info.Effects.Active},
+		)
+		on.add(boolValue(s.On.Value), controller)
+		brightness.add(float64(s.Brightness.Value), controller)
+		hue.add(float64(s.Hue.Value), controller)
+		sat.add(float64(s.Sat.Value), controller)
+		ct.add(float64(s.Ct.Value), controller)
+		panels.add(float64(lightPanels(r.info.PanelLayout.Layout)), controller)
+		rhythmConnected.add(boolValue(r.info.Rhythm.Connected), controller)
+
+		if r.info.Rhythm.Connected {
+			rhythmActive.add(boolValue(r.info.Rhythm.Active), controller)
+		}
+	}
+
+	return []*family{up, info, on, brightness, hue, sat, ct, panels, rhythmConnected, rhythmActive, duration}
+}
+
+// clientFamilies returns the recorded client metrics, the caller holds the lock
+func (e *Exporter) clientFamilies() []*family {
+	requests := &family{name: "nanoleaf_requests_total", help: "Requests sent to the api by status code", kind: "counter"}
+	latency := &family{name: "nanoleaf_request_duration_seconds", help: "Time until the response headers arrived", kind: "histogram"}
+	failed := &family{name: "nanoleaf_request_errors_total", help: "Failed requests by type", kind: "counter"}
+	packets := &family{name: "nanoleaf_stream_packets_total", help: "Packets written to the extControl stream", kind: "counter"}
+	sent := &family{name: "nanoleaf_stream_bytes_total", help: "Bytes written to the extControl stream", kind: "counter"}
+	streamErrs := &family{name: "nanoleaf_stream_errors_total", help: "Failed writes to the extControl stream", kind: "counter"}
+
+	e.requests.collect(requests)
+	e.latency.collect(latency)
+	e.errors.collect(failed)
+	e.packets.collect(packets)
+	e.bytes.collect(sent)
+	e.streamErrs.collect(streamErrs)
+
+	return []*family{requests, latency, failed, packets, sent, streamErrs}
+}
+
+// ObserveRequest implements nanoleaf.Observer
+func (o *observer) ObserveRequest(method, endpoint string, status int, duration time.Duration, err error) {
+	o.e.mu.Lock()
+	defer o.e.mu.Unlock()
+
+	controller := label{"controller", o.name}
+	if err != nil {
+		o.e.errors.add(1, controller, label{"type", errorType(0, err)})
+		return
+	}
+
+	code := strconv.Itoa(status)
+
+	o.e.requests.add(1, controller, label{"method", method}, label{"endpoint", endpoint}, label{"code", code})
+	o.e.latency.observe(duration.Seconds(), controller, label{"method", method}, label{"endpoint", endpoint})
+
+	if t := errorType(status, nil); t != "" {
+		o.e.errors.add(1, controller, label{"type", t})
+	}
+}
+
+// ObservePacket implements nanoleaf.Observer
+func (o *observer) ObservePacket(size int, err error) {
+	o.e.mu.Lock()
+	defer o.e.mu.Unlock()
+
+	controller := label{"controller", o.name}
+	if err != nil {
+		o.e.streamErrs.add(1, controller)
+		return
+	}
+
+	o.e.packets.add(1, controller)
+	o.e.bytes.add(float64(size), controller)
+}
+
+// errorType classifies failed requests, successful requests result in an empty type
+func errorType(status int, err error) string {
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return "canceled"
+		}
+
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			return "timeout"
+		}
+
+		return "network"
+	}
+
+	switch {
+	case status == http.StatusUnauthorized:
+		return "unauthorized"
+	case status == http.StatusNotFound:
+		return "not_found"
+	case status >= 500:
+		return "server_error"
+	case status >= 400:
+		return "client_error"
+	}
+
+	return ""
+}
+
+// lightPanels counts the panels of layout which are not controller units
+func lightPanels(layout nanoleaf.PanelLayout) int {
+	count := 0
+
+	for _, panel := range layout.PositionData {
+		if !panel.ShapeType.IsControllerUnit() {
+			count++
+		}
+	}
+
+	return count
+}
+
+// boolValue converts b into 1 or 0
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,257 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/adnanbrq/nanoleaf"
+)
+
+// serveController returns a client of a fake controller answering GET / with testdata/canvas.json
+func serveController(t *testing.T) *nanoleaf.Nanoleaf {
+	t.Helper()
+
+	body, err := ioutil.ReadFile(filepath.Join("..", "testdata", "canvas.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/token":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(body)
+		case "/api/v1/token/state/brightness":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	n := nanoleaf.NewNanoleaf(server.URL + "/api/v1")
+	n.SetToken("token")
+	return n
+}
+
+// scrape returns the exposition of e
+func scrape(t *testing.T, e *Exporter) string {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	e.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", w.Code, w.Body)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != contentType {
+		t.Errorf("got content type %q, want %q", ct, contentType)
+	}
+
+	return w.Body.String()
+}
+
+// assertLines fails if any of lines is missing in exposition
+func assertLines(t *testing.T, exposition string, lines ...string) {
+	t.Helper()
+
+	for _, line := range lines {
+		if !strings.Contains(exposition, line+"\n") {
+			t.Errorf("missing line %q in:\n%s", line, exposition)
+		}
+	}
+}
+
+func TestLabelSet(t *testing.T) {
+	tests := []struct {
+		labels []label
+		want   string
+	}{
+		{labels: nil, want: ""},
+		{labels: []label{{"controller", "canvas"}}, want: `{controller="canvas"}`},
+		{labels: []label{{"name", `Living "Room"`}, {"path", `C:\panels`}}, want: `{name="Living \"Room\"",path="C:\\panels"}`},
+		{labels: []label{{"name", "two\nlines"}}, want: `{name="two\nlines"}`},
+	}
+
+	for _, tt := range tests {
+		if got := labelSet(tt.labels...); got != tt.want {
+			t.Errorf("got %s, want %s", got, tt.want)
+		}
+	}
+}
+
+func TestFamilyWrite(t *testing.T) {
+	f := &family{name: "nanoleaf_up", help: "Whether the controller answered the last scrape", kind: "gauge"}
+	f.add(1, label{"controller", "a"})
+	f.add(0, label{"controller", "b"})
+
+	var b bytes.Buffer
+	if err := f.write(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "# HELP nanoleaf_up Whether the controller answered the last scrape\n" +
+		"# TYPE nanoleaf_up gauge\n" +
+		"nanoleaf_up{controller=\"a\"} 1\n" +
+		"nanoleaf_up{controller=\"b\"} 0\n"
+
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	if err := (&family{name: "empty", kind: "counter"}).write(&b); err != nil || b.Len() != 0 {
+		t.Errorf("got %q, families without samples should be skipped", b.String())
+	}
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	h := newHistogramVec([]float64{0.1, 1})
+	for _, v := range []float64{0.05, 0.1, 0.5, 3} {
+		h.observe(v, label{"controller", "a"})
+	}
+
+	f := &family{name: "latency", help: "h", kind: "histogram"}
+	h.collect(f)
+
+	var b bytes.Buffer
+	if err := f.write(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "# HELP latency h\n# TYPE latency histogram\n" +
+		"latency_bucket{controller=\"a\",le=\"0.1\"} 2\n" +
+		"latency_bucket{controller=\"a\",le=\"1\"} 3\n" +
+		"latency_bucket{controller=\"a\",le=\"+Inf\"} 4\n" +
+		"latency_sum{controller=\"a\"} 3.65\n" +
+		"latency_count{controller=\"a\"} 4\n"
+
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := map[float64]string{1: "1", 0.25: "0.25", 1e21: "1e+21", math.Inf(1): "+Inf", -math.Inf(1): "-Inf"}
+
+	for v, want := range tests {
+		if got := formatValue(v); got != want {
+			t.Errorf("formatValue(%v) = %s, want %s", v, got, want)
+		}
+	}
+}
+
+func TestExporter(t *testing.T) {
+	n := serveController(t)
+
+	e := NewExporter(time.Second)
+	e.Register("canvas", n)
+
+	if _, _, err := n.Raw(http.MethodGet, "state/brightness", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	exposition := scrape(t, e)
+
+	assertLines(t, exposition,
+		"# TYPE nanoleaf_up gauge",
+		`nanoleaf_up{controller="canvas"} 1`,
+		`nanoleaf_info{controller="canvas",name="Canvas 7E3A",model="NL29",serial="S19041C2731",firmware_version="4.1.3",color_mode="hs",effect="*Solid*"} 1`,
+		`nanoleaf_on{controller="canvas"} 0`,
+		`nanoleaf_brightness{controller="canvas"} 42`,
+		`nanoleaf_panels{controller="canvas"} 4`,
+		`nanoleaf_rhythm_active{controller="canvas"} 1`,
+		"# TYPE nanoleaf_requests_total counter",
+		`nanoleaf_requests_total{controller="canvas",method="GET",endpoint="/",code="200"} 1`,
+		`nanoleaf_requests_total{controller="canvas",method="GET",endpoint="state/brightness",code="500"} 1`,
+		"# TYPE nanoleaf_request_duration_seconds histogram",
+		`nanoleaf_request_duration_seconds_count{controller="canvas",method="GET",endpoint="/"} 1`,
+		`nanoleaf_request_errors_total{controller="canvas",type="server_error"} 1`,
+	)
+
+	if strings.Contains(exposition, "token") {
+		t.Errorf("token exposed in:\n%s", exposition)
+	}
+
+	e.Unregister("canvas")
+	if exposition = scrape(t, e); strings.Contains(exposition, "nanoleaf_up") {
+		t.Errorf("unregistered controller scraped:\n%s", exposition)
+	}
+}
+
+func TestExporterScrapeTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+
+	n := nanoleaf.NewNanoleaf(server.URL + "/api/v1")
+	n.SetToken("token")
+
+	e := NewExporter(50 * time.Millisecond)
+	e.Register("slow", n)
+
+	start := time.Now()
+	exposition := scrape(t, e)
+
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("scrape took %v, want it to stop after the scrape timeout", d)
+	}
+
+	assertLines(t, exposition,
+		`nanoleaf_up{controller="slow"} 0`,
+		`nanoleaf_request_errors_total{controller="slow",type="timeout"} 1`,
+	)
+
+	if strings.Contains(exposition, "nanoleaf_requests_total") {
+		t.Errorf("requests without a response should only count as errors:\n%s", exposition)
+	}
+}
+
+func TestExporterTransportErrors(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	n := nanoleaf.NewNanoleaf("http://" + addr + "/api/v1")
+	n.SetToken("token")
+
+	e := NewExporter(time.Second)
+	e.Register("offline", n)
+
+	if err := n.State.SetBrightness(50, 0); err == nil {
+		t.Fatal("got no error from a refused connection")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := n.GetControllerInfoContext(ctx); err == nil {
+		t.Fatal("got no error from a cancelled context")
+	}
+
+	exposition := scrape(t, e)
+
+	assertLines(t, exposition,
+		`nanoleaf_up{controller="offline"} 0`,
+		`nanoleaf_request_errors_total{controller="offline",type="canceled"} 1`,
+		`nanoleaf_request_errors_total{controller="offline",type="network"} 2`,
+	)
+}
@@ -0,0 +1,389 @@
+// Package mqttbridge publishes the state of nanoleaf controllers to MQTT and accepts commands from it.
+//
+// Every device is available below <prefix>/<id>/ (the prefix defaults to "nanoleaf"):
+//
+//	state            retained json state: {"state": "ON", "brightness": 80, "color_mode": "hs", "color": {"h": 120, "s": 100}, "effect": "Flames"}
+//	availability     retained "online" or "offline", <prefix>/bridge/availability reports the bridge itself
+//	set              json command as sent by home assistant: {"state", "brightness", "color", "color_temp", "effect", "transition"}
+//	set/on           "ON" or "OFF"
+//	set/brightness   0-100
+//	set/hue          0-359
+//	set/sat          0-100
+//	set/ct           color temperature in kelvin
+//	set/effect       name of the effect
+//
+// Home assistant discovery configs are published to <discovery prefix>/light/nanoleaf_<id>/config.
+// Brightness is scaled 0-100 and color temperatures are sent in mireds as expected by home assistant.
+package mqttbridge
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/adnanbrq/nanoleaf"
+)
+
+// bridge defaults
+const (
+	DefaultTopicPrefix     = "nanoleaf"
+	DefaultDiscoveryPrefix = "homeassistant"
+	DefaultPollInterval    = 30 * time.Second
+)
+
+// payloads of the availability topics
+const (
+	online  = "online"
+	offline = "offline"
+)
+
+// qos used for every message
+const qos = 1
+
+// validID allowed device ids, they are used as topic levels
+var validID = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// Options configures a bridge, zero values are replaced by the defaults
+type Options struct {
+	// TopicPrefix of every state and command topic
+	TopicPrefix string
+	// DiscoveryPrefix home assistant listens to for discovery configs
+	DiscoveryPrefix string
+	// DisableDiscovery stops publishing home assistant discovery configs
+	DisableDiscovery bool
+	// PollInterval how often the state is polled besides listening to events
+	PollInterval time.Duration
+}
+
+// Device a controller published by the bridge
+type Device struct {
+	ID     string
+	Client *nanoleaf.Nanoleaf
+}
+
+// device a device and its last known state
+type device struct {
+	id      string
+	client  *nanoleaf.Nanoleaf
+	mu      sync.Mutex
+	cmd     sync.Mutex
+	info    *nanoleaf.ControllerInfo
+	effects []string
+	config  []byte
+}
+
+// Bridge connects nanoleaf controllers with an MQTT broker
+type Bridge struct {
+	opts    Options
+	client  mqtt.Client
+	devices map[string]*device
+	errs    chan error
+}
+
+// New returns a bridge for devices using the broker configured in mqttOpts. The bridge sets the will and
+// the on connect handler of mqttOpts to report its availability and to resubscribe after reconnects
+func New(mqttOpts *mqtt.ClientOptions, opts Options, devices ...Device) (*Bridge, error) {
+	if len(devices) == 0 {
+		return nil, ErrNoDevices
+	}
+
+	if opts.TopicPrefix == "" {
+		opts.TopicPrefix = DefaultTopicPrefix
+	}
+
+	if opts.DiscoveryPrefix == "" {
+		opts.DiscoveryPrefix = DefaultDiscoveryPrefix
+	}
+
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+
+	b := &Bridge{
+		opts:    opts,
+		devices: map[string]*device{},
+		errs:    make(chan error, 16),
+	}
+
+	for _, d := range devices {
+		if !validID.MatchString(d.ID) || d.ID == "bridge" {
+			return nil, ErrInvalidDeviceID
+		}
+
+		b.devices[d.ID] = &device{id: d.ID, client: d.Client}
+	}
+
+	mqttOpts.SetWill(b.topic("bridge", "availability"), offline, qos, true)
+	mqttOpts.SetOnConnectHandler(b.onConnect)
+	b.client = mqtt.NewClient(mqttOpts)
+
+	return b, nil
+}
+
+// Errors returns failed commands and publishes. Errors are dropped if nobody reads them
+func (b *Bridge) Errors() <-chan error {
+	return b.errs
+}
+
+// Run connects to the broker and keeps the published state up to date until ctx is cancelled.
+// Devices are marked offline before disconnecting
+func (b *Bridge) Run(ctx context.Context) error {
+	if token := b.client.Connect(); token.Wait() && token.Error() != nil {
+		return token.Error()
+	}
+
+	var wg sync.WaitGroup
+	for _, d := range b.devices {
+		wg.Add(2)
+
+		go func(d *device) {
+			defer wg.Done()
+			b.poll(ctx, d)
+		}(d)
+
+		go func(d *device) {
+			defer wg.Done()
+			b.listen(ctx, d)
+		}(d)
+	}
+
+	<-ctx.Done()
+	wg.Wait()
+
+	for id := range b.devices {
+		b.publish(b.topic(id, "availability"), offline, true)
+	}
+
+	b.publish(b.topic("bridge", "availability"), offline, true)
+	b.client.Disconnect(250)
+
+	return ctx.Err()
+}
+
+// onConnect subscribes to the command topics and republishes everything known after every (re)connect
+func (b *Bridge) onConnect(client mqtt.Client) {
+	topics := map[string]byte{
+		b.topic("+", "set"):   qos,
+		b.topic("+", "set/+"): qos,
+	}
+
+	if token := client.SubscribeMultiple(topics, b.handleCommand); token.Wait() && token.Error() != nil {
+		b.fail(token.Error())
+	}
+
+	b.publish(b.topic("bridge", "availability"), online, true)
+
+	for _, d := range b.devices {
+		d.mu.Lock()
+		d.config = nil
+		info := d.info
+		d.mu.Unlock()
+
+		if info != nil {
+			b.publishState(d, info)
+		}
+	}
+}
+
+// poll refreshes the state of d every poll interval
+func (b *Bridge) poll(ctx context.Context, d *device) {
+	ticker := time.NewTicker(b.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		b.refresh(d)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// listen applies state and effects events to the published state, the stream is reopened if it breaks
+func (b *Bridge) listen(ctx context.Context, d *device) {
+	for {
+		err := d.client.Events.Listen(ctx, func(event nanoleaf.Event) {
+			d.mu.Lock()
+			info := d.info
+			applied := info != nil && info.ApplyEvent(event)
+			d.mu.Unlock()
+
+			if applied {
+				b.publishState(d, info)
+			} else {
+				b.refresh(d)
+			}
+		}, nanoleaf.EventTypeState, nanoleaf.EventTypeEffects)
+
+		if err != nil && ctx.Err() == nil {
+			b.fail(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(b.opts.PollInterval):
+		}
+	}
+}
+
+// refresh fetches the state and effects of d and publishes them, d is reported offline if the controller
+// is unreachable. The last known effects are kept if listing them fails
+func (b *Bridge) refresh(d *device) {
+	info, err := d.client.GetControllerInfo()
+	if err != nil {
+		b.publish(b.topic(d.id, "availability"), offline, true)
+		b.fail(err)
+		return
+	}
+
+	effects, err := d.client.Effects.List()
+	if err != nil {
+		b.fail(err)
+	}
+
+	d.mu.Lock()
+	d.info = info
+	if err == nil {
+		d.effects = effects
+	}
+	d.mu.Unlock()
+
+	b.publishState(d, info)
+}
+
+// publishState publishes state and availability of d and its discovery config whenever it changed
+func (b *Bridge) publishState(d *device, info *nanoleaf.ControllerInfo) {
+	d.mu.Lock()
+	state, err := json.Marshal(stateOf(info))
+	config, _ := json.Marshal(b.discoveryConfig(d, info, d.effects))
+	changed := string(config) != string(d.config)
+	d.config = config
+	d.mu.Unlock()
+
+	if err != nil {
+		b.fail(err)
+		return
+	}
+
+	if changed && !b.opts.DisableDiscovery {
+		b.publish(b.opts.DiscoveryPrefix+"/light/nanoleaf_"+d.id+"/config", config, true)
+	}
+
+	b.publish(b.topic(d.id, "state"), state, true)
+	b.publish(b.topic(d.id, "availability"), online, true)
+}
+
+// handleCommand dispatches a message of a command topic. Commands run outside of the message router
+// since they wait for the controller and publish the resulting state
+func (b *Bridge) handleCommand(client mqtt.Client, msg mqtt.Message) {
+	levels := strings.Split(strings.TrimPrefix(msg.Topic(), b.opts.TopicPrefix+"/"), "/")
+	if len(levels) < 2 {
+		return
+	}
+
+	d, ok := b.devices[levels[0]]
+	if !ok {
+		return
+	}
+
+	attribute := ""
+	if len(levels) == 3 {
+		attribute = levels[2]
+	}
+
+	go b.command(d, attribute, msg.Payload())
+}
+
+// command applies a json command (empty attribute) or a plain payload of set/<attribute> and publishes
+// the resulting state. Commands of the same device are applied one after another
+func (b *Bridge) command(d *device, attribute string, payload []byte) {
+	d.cmd.Lock()
+	defer d.cmd.Unlock()
+
+	var err error
+	if attribute == "" {
+		var cmd haCommand
+		if err = json.Unmarshal(payload, &cmd); err != nil {
+			err = ErrInvalidCommand
+		} else {
+			err = cmd.Apply(d.client)
+		}
+	} else {
+		err = applySimple(d.client, attribute, strings.TrimSpace(string(payload)))
+	}
+
+	if err != nil {
+		b.fail(err)
+	}
+
+	b.refresh(d)
+}
+
+// applySimple applies a plain payload sent to set/<attribute>
+func applySimple(client *nanoleaf.Nanoleaf, attribute, payload string) error {
+	if attribute == "effect" {
+		if payload == "" {
+			return ErrInvalidCommand
+		}
+
+		return client.Effects.Set(payload)
+	}
+
+	if attribute == "on" {
+		switch strings.ToUpper(payload) {
+		case "ON", "TRUE", "1":
+			return client.State.SetOn(true)
+		case "OFF", "FALSE", "0":
+			return client.State.SetOn(false)
+		}
+
+		return ErrInvalidCommand
+	}
+
+	value, err := strconv.Atoi(payload)
+	if err != nil {
+		return ErrInvalidCommand
+	}
+
+	switch {
+	case attribute == "brightness" && value >= 0 && value <= 100:
+		return client.State.SetBrightness(value, 0)
+	case attribute == "hue" && value >= 0 && value <= 359:
+		return client.State.SetHue(value, false)
+	case attribute == "sat" && value >= 0 && value <= 100:
+		return client.State.SetSaturation(value, false)
+	case attribute == "ct" && value >= minKelvin && value <= maxKelvin:
+		return client.State.SetColorTemp(value, false)
+	}
+
+	return ErrInvalidCommand
+}
+
+// publish publishes payload and reports failures
+func (b *Bridge) publish(topic string, payload interface{}, retained bool) {
+	if token := b.client.Publish(topic, qos, retained, payload); token.WaitTimeout(5*time.Second) && token.Error() != nil {
+		b.fail(token.Error())
+	}
+}
+
+// fail reports err without blocking
+func (b *Bridge) fail(err error) {
+	select {
+	case b.errs <- err:
+	default:
+	}
+}
+
+// topic returns the topic of suffix below the device id
+func (b *Bridge) topic(id, suffix string) string {
+	return b.opts.TopicPrefix + "/" + id + "/" + suffix
+}
@@ -0,0 +1,443 @@
+package mqttbridge
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/adnanbrq/nanoleaf"
+)
+
+// message a publish seen by the broker
+type message struct {
+	topic    string
+	payload  string
+	retained bool
+}
+
+// broker minimal in-process MQTT 3.1.1 broker supporting CONNECT, SUBSCRIBE, PUBLISH (qos 0 and 1),
+// PINGREQ and DISCONNECT. Every publish is forwarded with qos 0 and recorded
+type broker struct {
+	listener net.Listener
+	mu       sync.Mutex
+	subs     map[net.Conn][]string
+	messages []message
+}
+
+// newBroker starts a broker on loopback
+func newBroker(t *testing.T) *broker {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &broker{listener: listener, subs: map[net.Conn][]string{}}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			con, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go b.serve(con)
+		}
+	}()
+
+	return b
+}
+
+// url returns the broker url as expected by paho
+func (b *broker) url() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+// published returns the last message published to topic
+func (b *broker) published(topic string) (message, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i := len(b.messages) - 1; i >= 0; i-- {
+		if b.messages[i].topic == topic {
+			return b.messages[i], true
+		}
+	}
+
+	return message{}, false
+}
+
+// serve handles the packets of a single client
+func (b *broker) serve(con net.Conn) {
+	defer func() {
+		b.mu.Lock()
+		delete(b.subs, con)
+		b.mu.Unlock()
+		con.Close()
+	}()
+
+	r := bufio.NewReader(con)
+	for {
+		header, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+
+		switch header >> 4 {
+		case 1: // CONNECT
+			con.Write(encodePacket(0x20, []byte{0, 0}))
+		case 3: // PUBLISH
+			n := int(binary.BigEndian.Uint16(body))
+			topic, rest := string(body[2:2+n]), body[2+n:]
+
+			if (header>>1)&3 > 0 {
+				con.Write(encodePacket(0x40, rest[:2]))
+				rest = rest[2:]
+			}
+
+			b.publish(message{topic: topic, payload: string(rest), retained: header&1 == 1})
+		case 8: // SUBSCRIBE
+			id, rest := body[:2], body[2:]
+			ack := append([]byte{}, id...)
+
+			var filters []string
+			for len(rest) > 0 {
+				n := int(binary.BigEndian.Uint16(rest))
+				filters = append(filters, string(rest[2:2+n]))
+				rest = rest[3+n:]
+				ack = append(ack, 1)
+			}
+
+			b.mu.Lock()
+			b.subs[con] = append(b.subs[con], filters...)
+			b.mu.Unlock()
+
+			con.Write(encodePacket(0x90, ack))
+		case 12: // PINGREQ
+			con.Write(encodePacket(0xd0, nil))
+		case 14: // DISCONNECT
+			return
+		}
+	}
+}
+
+// publish records m and forwards it to every matching subscription
+func (b *broker) publish(m message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.messages = append(b.messages, m)
+	packet := encodePacket(0x30, append(encodeString(m.topic), m.payload...))
+
+	for con, filters := range b.subs {
+		for _, filter := range filters {
+			if matchTopic(filter, m.topic) {
+				con.Write(packet)
+				break
+			}
+		}
+	}
+}
+
+// readPacket reads fixed header and body of a packet
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	length, multiplier := 0, 1
+	for {
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		length += int(digit&127) * multiplier
+		multiplier *= 128
+
+		if digit&128 == 0 {
+			break
+		}
+	}
+
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return header, body, err
+}
+
+// encodePacket prefixes body with the fixed header
+func encodePacket(header byte, body []byte) []byte {
+	packet := []byte{header}
+
+	for length := len(body); ; {
+		digit := byte(length % 128)
+		length /= 128
+
+		if length > 0 {
+			digit |= 128
+		}
+
+		packet = append(packet, digit)
+		if length == 0 {
+			break
+		}
+	}
+
+	return append(packet, body...)
+}
+
+// encodeString encodes s with its length prefix
+func encodeString(s string) []byte {
+	b := make([]byte, 2, 2+len(s))
+	binary.BigEndian.PutUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// matchTopic checks if topic matches filter including + and # wildcards
+func matchTopic(filter, topic string) bool {
+	f, t := strings.Split(filter, "/"), strings.Split(topic, "/")
+
+	for i := range f {
+		if f[i] == "#" {
+			return true
+		}
+
+		if i >= len(t) || (f[i] != "+" && f[i] != t[i]) {
+			return false
+		}
+	}
+
+	return len(f) == len(t)
+}
+
+// controller fake nanoleaf api recording every write
+type controller struct {
+	mu     sync.Mutex
+	writes []string
+}
+
+// written returns the recorded writes
+func (c *controller) written() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string{}, c.writes...)
+}
+
+// ServeHTTP implements http.Handler
+func (c *controller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/token":
+		w.Write([]byte(`{
+			"name": "Shapes AC09", "serialNo": "S20124C8036", "model": "NL42", "firmwareVersion": "7.1.3",
+			"state": {
+				"on": {"value": true}, "brightness": {"value": 80, "max": 100, "min": 0},
+				"hue": {"value": 120, "max": 360, "min": 0}, "sat": {"value": 100, "max": 100, "min": 0},
+				"ct": {"value": 4000, "max": 6500, "min": 1200}, "colorMode": "hs"
+			},
+			"effects": {"select": "Sunset", "effectsList": ["Sunset"]}
+		}`))
+	case r.Method == http.MethodGet && r.URL.Path == "/token/effects/effectsList":
+		w.Write([]byte(`["Beatdrop", "Blaze", "Sunset"]`))
+	case r.Method == http.MethodGet && r.URL.Path == "/token/events":
+		w.WriteHeader(http.StatusUnauthorized)
+	case r.Method == http.MethodPut:
+		c.mu.Lock()
+		c.writes = append(c.writes, r.URL.Path+" "+string(body))
+		c.mu.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// waitFor fails the test if cond does not become true within two seconds
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); {
+		if cond() {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+// errorLog collects the errors of a bridge
+type errorLog struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+// contains checks if err has been reported
+func (l *errorLog) contains(err error) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, e := range l.errs {
+		if e == err {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestBridge(t *testing.T) {
+	ctrl := &controller{}
+	server := httptest.NewServer(ctrl)
+	defer server.Close()
+
+	mqttBroker := newBroker(t)
+
+	client := nanoleaf.NewNanoleaf(server.URL)
+	client.SetToken("token")
+
+	bridge, err := New(mqtt.NewClientOptions().AddBroker(mqttBroker.url()), Options{PollInterval: time.Hour}, Device{ID: "living", Client: client})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := &errorLog{}
+	go func() {
+		for err := range bridge.Errors() {
+			errs.mu.Lock()
+			errs.errs = append(errs.errs, err)
+			errs.mu.Unlock()
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- bridge.Run(ctx) }()
+
+	t.Run("discovery", func(t *testing.T) {
+		var m message
+		waitFor(t, "discovery config", func() bool {
+			var ok bool
+			m, ok = mqttBroker.published("homeassistant/light/nanoleaf_living/config")
+			return ok
+		})
+
+		var config haConfig
+		if err := json.Unmarshal([]byte(m.payload), &config); err != nil {
+			t.Fatal(err)
+		}
+
+		if !m.retained || config.Schema != "json" || config.CommandTopic != "nanoleaf/living/set" || config.StateTopic != "nanoleaf/living/state" {
+			t.Errorf("got config %s retained %v", m.payload, m.retained)
+		}
+
+		if strings.Join(config.EffectList, ",") != "Beatdrop,Blaze,Sunset" {
+			t.Errorf("got effect list %v", config.EffectList)
+		}
+	})
+
+	t.Run("state", func(t *testing.T) {
+		m, ok := mqttBroker.published("nanoleaf/living/state")
+		if !ok || !m.retained {
+			t.Fatalf("got state %+v", m)
+		}
+
+		var state haState
+		if err := json.Unmarshal([]byte(m.payload), &state); err != nil {
+			t.Fatal(err)
+		}
+
+		if state.State != "ON" || state.Brightness != 80 || state.ColorMode != "hs" || state.Color == nil || state.Color.H != 120 {
+			t.Errorf("got state %s", m.payload)
+		}
+
+		for _, topic := range []string{"nanoleaf/living/availability", "nanoleaf/bridge/availability"} {
+			if m, _ := mqttBroker.published(topic); m.payload != online {
+				t.Errorf("got %q on %s", m.payload, topic)
+			}
+		}
+	})
+
+	t.Run("listen errors", func(t *testing.T) {
+		waitFor(t, "event stream error", func() bool { return errs.contains(nanoleaf.ErrUnauthorized) })
+	})
+
+	t.Run("commands", func(t *testing.T) {
+		sender := mqtt.NewClient(mqtt.NewClientOptions().AddBroker(mqttBroker.url()).SetClientID("sender"))
+		if token := sender.Connect(); token.Wait() && token.Error() != nil {
+			t.Fatal(token.Error())
+		}
+		defer sender.Disconnect(0)
+
+		publish := func(topic, payload string) {
+			if token := sender.Publish(topic, 1, false, payload); token.Wait() && token.Error() != nil {
+				t.Fatal(token.Error())
+			}
+		}
+
+		publish("nanoleaf/living/set/hue", "360")
+		waitFor(t, "invalid command error", func() bool { return errs.contains(ErrInvalidCommand) })
+
+		publish("nanoleaf/living/set", `{"state": "ON", "brightness": 60, "transition": 2}`)
+		publish("nanoleaf/living/set/effect", "Blaze")
+		publish("nanoleaf/living/set/on", "OFF")
+
+		// commands of different messages may run in any order
+		want := []string{
+			`/token/effects {"select":"Blaze"}`,
+			`/token/state {"brightness":{"duration":2,"value":60}}`,
+			`/token/state {"on":{"value":false}}`,
+		}
+
+		waitFor(t, "controller writes", func() bool { return len(ctrl.written()) >= len(want) })
+
+		got := ctrl.written()
+		sort.Strings(got)
+
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("got writes %v, want %v", got, want)
+		}
+	})
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Run returned %v", err)
+	}
+
+	if m, _ := mqttBroker.published("nanoleaf/living/availability"); m.payload != offline {
+		t.Errorf("got availability %q after shutdown", m.payload)
+	}
+}
+
+func TestNewInvalidDevices(t *testing.T) {
+	opts := mqtt.NewClientOptions()
+
+	if _, err := New(opts, Options{}); err != ErrNoDevices {
+		t.Errorf("got %v, want ErrNoDevices", err)
+	}
+
+	for _, id := range []string{"", "bridge", "living room", "a/b", "+"} {
+		if _, err := New(opts, Options{}, Device{ID: id, Client: nanoleaf.NewNanoleaf("")}); err != ErrInvalidDeviceID {
+			t.Errorf("id %q: got %v, want ErrInvalidDeviceID", id, err)
+		}
+	}
+}
@@ -0,0 +1,14 @@
+package mqttbridge
+
+import "errors"
+
+var (
+	// ErrNoDevices occurs if a bridge is run without any device
+	ErrNoDevices = errors.New("At least one device is required")
+
+	// ErrInvalidDeviceID occurs if a device id is empty or contains characters not allowed in topics
+	ErrInvalidDeviceID = errors.New("Invalid device id given")
+
+	// ErrInvalidCommand occurs if a command payload could not be parsed or contains values out of range
+	ErrInvalidCommand = errors.New("Invalid command given")
+)
@@ -0,0 +1,246 @@
+package mqttbridge
+
+import (
+	"math"
+
+	"github.com/adnanbrq/nanoleaf"
+)
+
+// color temperature range of the controllers in kelvin
+const (
+	minKelvin = 1200
+	maxKelvin = 6500
+)
+
+// haDevice device block of a home assistant discovery config
+type haDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+	Model        string   `json:"model"`
+	SWVersion    string   `json:"sw_version,omitempty"`
+	HWVersion    string   `json:"hw_version,omitempty"`
+}
+
+// haAvailability availability entry of a home assistant discovery config
+type haAvailability struct {
+	Topic string `json:"topic"`
+}
+
+// haConfig home assistant mqtt discovery config of a light using the json schema
+type haConfig struct {
+	Name                string           `json:"name"`
+	UniqueID            string           `json:"unique_id"`
+	Schema              string           `json:"schema"`
+	StateTopic          string           `json:"state_topic"`
+	CommandTopic        string           `json:"command_topic"`
+	Availability        []haAvailability `json:"availability"`
+	AvailabilityMode    string           `json:"availability_mode"`
+	Brightness          bool             `json:"brightness"`
+	BrightnessScale     int              `json:"brightness_scale"`
+	SupportedColorModes []string         `json:"supported_color_modes"`
+	MinMireds           int              `json:"min_mireds"`
+	MaxMireds           int              `json:"max_mireds"`
+	Effect              bool             `json:"effect"`
+	EffectList          []string         `json:"effect_list"`
+	Device              haDevice         `json:"device"`
+}
+
+// haColor hs color as used by home assistant, hue 0-360 and saturation 0-100
+type haColor struct {
+	H float64 `json:"h"`
+	S float64 `json:"s"`
+}
+
+// haState state payload of the json schema
+type haState struct {
+	State      string   `json:"state"`
+	Brightness int      `json:"brightness"`
+	ColorMode  string   `json:"color_mode"`
+	Color      *haColor `json:"color,omitempty"`
+	ColorTemp  int      `json:"color_temp,omitempty"`
+	Effect     string   `json:"effect,omitempty"`
+}
+
+// haCommand command payload of the json schema
+type haCommand struct {
+	State      string   `json:"state"`
+	Brightness *int     `json:"brightness"`
+	Color      *haColor `json:"color"`
+	ColorTemp  *int     `json:"color_temp"`
+	Effect     *string  `json:"effect"`
+	// Transition in seconds
+	Transition float64 `json:"transition"`
+}
+
+// discoveryConfig returns the discovery config of a device
+func (b *Bridge) discoveryConfig(d *device, info *nanoleaf.ControllerInfo, effects []string) haConfig {
+	name := info.Name
+	if name == "" {
+		name = d.id
+	}
+
+	identifier := "nanoleaf_" + d.id
+	if info.Serial != "" {
+		identifier = "nanoleaf_" + info.Serial
+	}
+
+	if effects == nil {
+		effects = []string{}
+	}
+
+	return haConfig{
+		Name:         name,
+		UniqueID:     "nanoleaf_" + d.id,
+		Schema:       "json",
+		StateTopic:   b.topic(d.id, "state"),
+		CommandTopic: b.topic(d.id, "set"),
+		Availability: []haAvailability{
+			{Topic: b.topic("bridge", "availability")},
+			{Topic: b.topic(d.id, "availability")},
+		},
+		AvailabilityMode:    "all",
+		Brightness:          true,
+		BrightnessScale:     100,
+		SupportedColorModes: []string{"hs", "color_temp"},
+		MinMireds:           kelvinToMireds(maxKelvin),
+		MaxMireds:           kelvinToMireds(minKelvin),
+		Effect:              true,
+		EffectList:          effects,
+		Device: haDevice{
+			Identifiers:  []string{identifier},
+			Name:         name,
+			Manufacturer: "Nanoleaf",
+			Model:        info.Model,
+			SWVersion:    info.FirmwareVersion,
+			HWVersion:    info.HardwareVersion,
+		},
+	}
+}
+
+// stateOf converts the controllers state into the json schema. Effects are reported with their hs color
+// since home assistant requires one of the supported color modes
+func stateOf(info *nanoleaf.ControllerInfo) haState {
+	s := info.State
+	state := haState{
+		State:      "OFF",
+		Brightness: s.Brightness.Value,
+		ColorMode:  "hs",
+		Color:      &haColor{H: float64(s.Hue.Value), S: float64(s.Sat.Value)},
+	}
+
+	if s.On.Value {
+		state.State = "ON"
+	}
+
+	switch s.ColorMode {
+	case "ct":
+		state.ColorMode = "color_temp"
+		state.Color = nil
+		state.ColorTemp = kelvinToMireds(s.Ct.Value)
+	case "effect":
+		state.Effect = info.Effects.Active
+	}
+
+	return state
+}
+
+// Apply validates the command and sends it to client. The panels are turned off last since every
+// other change turns them on
+func (c haCommand) Apply(client *nanoleaf.Nanoleaf) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if c.Effect != nil {
+		if err := client.Effects.Set(*c.Effect); err != nil {
+			return err
+		}
+	}
+
+	if c.Color != nil {
+		if err := client.State.SetHue(int(math.Round(c.Color.H))%360, false); err != nil {
+			return err
+		}
+
+		if err := client.State.SetSaturation(int(math.Round(c.Color.S)), false); err != nil {
+			return err
+		}
+	}
+
+	if c.ColorTemp != nil {
+		kelvin := clamp(miredsToKelvin(*c.ColorTemp), minKelvin, maxKelvin)
+		if err := client.State.SetColorTemp(kelvin, false); err != nil {
+			return err
+		}
+	}
+
+	if c.Brightness != nil {
+		if err := client.State.SetBrightness(*c.Brightness, int(math.Round(c.Transition))); err != nil {
+			return err
+		}
+	}
+
+	switch c.State {
+	case "ON":
+		if c.Brightness == nil && c.Color == nil && c.ColorTemp == nil && c.Effect == nil {
+			return client.State.SetOn(true)
+		}
+	case "OFF":
+		return client.State.SetOn(false)
+	}
+
+	return nil
+}
+
+// Validate checks the ranges of every given value
+func (c haCommand) Validate() error {
+	if c.State != "" && c.State != "ON" && c.State != "OFF" {
+		return ErrInvalidCommand
+	}
+
+	if c.Brightness != nil && (*c.Brightness < 0 || *c.Brightness > 100) {
+		return ErrInvalidCommand
+	}
+
+	if c.Color != nil && (c.Color.H < 0 || c.Color.H > 360 || c.Color.S < 0 || c.Color.S > 100) {
+		return ErrInvalidCommand
+	}
+
+	if c.ColorTemp != nil && *c.ColorTemp <= 0 {
+		return ErrInvalidCommand
+	}
+
+	if c.Transition < 0 || (c.Effect != nil && *c.Effect == "") {
+		return ErrInvalidCommand
+	}
+
+	return nil
+}
+
+// kelvinToMireds converts a color temperature from kelvin into mireds
+func kelvinToMireds(kelvin int) int {
+	if kelvin <= 0 {
+		return 0
+	}
+
+	return int(math.Round(1e6 / float64(kelvin)))
+}
+
+// miredsToKelvin converts a color temperature from mireds into kelvin
+func miredsToKelvin(mireds int) int {
+	return int(math.Round(1e6 / float64(mireds)))
+}
+
+// clamp limits v to min and max
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+
+	if v > max {
+		return max
+	}
+
+	return v
+}
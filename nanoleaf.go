@@ -1,9 +1,12 @@
 package nanoleaf
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync/atomic"
 
 	"github.com/go-resty/resty/v2"
 )
@@ -13,9 +16,13 @@ type Nanoleaf struct {
 	client   *resty.Client
 	url      string
 	token    string
+	observer atomic.Value
 	Identity *NanoIdentity
 	Auth     *NanoAuth
 	Effects  *NanoEffects
+	Events   *NanoEvents
+	Plugins  *NanoPlugins
+	Rhythm   *NanoRhythm
 	State    *NanoState
 	Stream   *NanoStream
 	Layout   *NanoLayout
@@ -26,55 +33,80 @@ type jsonPayload map[string]interface{}
 
 // ControllerInfo as a go struct
 type ControllerInfo struct {
-	Name            string `json:"name"`
-	Serial          string `json:"serialNo"`
-	Manufacturer    string `json:"manufacturer"`
-	FirmwareVersion string `json:"firmwareVersion"`
-	Model           string `json:"model"`
-	State           struct {
-		On struct {
-			Value bool `json:"value"`
-		} `json:"on"`
-		Brightness struct {
-			Value int `json:"value"`
-			Max   int `json:"max"`
-			Min   int `json:"min"`
-		} `json:"brightness"`
-		Hue struct {
-			Value int `json:"value"`
-			Max   int `json:"max"`
-			Min   int `json:"min"`
-		} `json:"hue"`
-		Sat struct {
-			Value int `json:"value"`
-			Max   int `json:"max"`
-			Min   int `json:"min"`
-		} `json:"sat"`
-		Ct struct {
-			Value int `json:"value"`
-			Max   int `json:"max"`
-			Min   int `json:"min"`
-		} `json:"ct"`
-		ColorMode string `json:"colorMode"`
-	} `json:"state"`
-	Effects struct {
-		Active string   `json:"select"`
-		List   []string `json:"effectsList"`
-	} `json:"effects"`
-	PanelLayout struct {
-		Layout            PanelLayout `json:"layout"`
-		GlobalOrientation `json:"globalOrientation"`
-	} `json:"panelLayout"`
-	Rythm struct {
-		Connected       bool   `json:"rythmConnected"`
-		Active          bool   `json:"rythmActive"`
-		ID              bool   `json:"rythmId"`
-		HardwareVersion string `json:"hardwareVersion"`
-		FirmwareVersion string `json:"firmwareVersion"`
-		AuxAvailable    bool   `json:"auxAvailable"`
-		Mode            string `json:"rythmMode"`
-		Pos             string `json:"rythmPos"`
-	} `json:"rythm"`
+	Name            string                `json:"name"`
+	Serial          string                `json:"serialNo"`
+	Manufacturer    string                `json:"manufacturer"`
+	FirmwareVersion string                `json:"firmwareVersion"`
+	HardwareVersion string                `json:"hardwareVersion"`
+	Model           string                `json:"model"`
+	State           ControllerState       `json:"state"`
+	Effects         ControllerEffects     `json:"effects"`
+	PanelLayout     ControllerPanelLayout `json:"panelLayout"`
+	Rhythm          RhythmInfo            `json:"rhythm"`
+
+	// Blocks without a documented layout are kept as sent by the controller
+	Discovery       json.RawMessage `json:"discovery"`
+	FirmwareUpgrade json.RawMessage `json:"firmwareUpgrade"`
+	Schedules       json.RawMessage `json:"schedules"`
+	CloudHash       json.RawMessage `json:"cloudHash"`
+
+	// Rythm is a copy of Rhythm filled when decoding.
+	//
+	// Deprecated: use Rhythm instead
+	Rythm RhythmInfo `json:"-"`
+}
+
+// ControllerState state block of ControllerInfo
+type ControllerState struct {
+	On         OnOff            `json:"on"`
+	Brightness Brightness       `json:"brightness"`
+	Hue        Hue              `json:"hue"`
+	Sat        Saturation       `json:"sat"`
+	Ct         ColorTemperature `json:"ct"`
+	ColorMode  string           `json:"colorMode"`
+}
+
+// ControllerEffects effects block of ControllerInfo
+type ControllerEffects struct {
+	Active string   `json:"select"`
+	List   []string `json:"effectsList"`
+}
+
+// ControllerPanelLayout panelLayout block of ControllerInfo
+type ControllerPanelLayout struct {
+	Layout            PanelLayout       `json:"layout"`
+	GlobalOrientation GlobalOrientation `json:"globalOrientation"`
+}
+
+// RhythmPosition position of the rhythm module in layout space
+type RhythmPosition struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	O float64 `json:"o"`
+}
+
+// RhythmInfo rhythm block of ControllerInfo
+type RhythmInfo struct {
+	Connected       bool           `json:"rhythmConnected"`
+	Active          bool           `json:"rhythmActive"`
+	ID              int            `json:"rhythmId"`
+	HardwareVersion string         `json:"hardwareVersion"`
+	FirmwareVersion string         `json:"firmwareVersion"`
+	AuxAvailable    bool           `json:"auxAvailable"`
+	Mode            RhythmMode     `json:"rhythmMode"`
+	Pos             RhythmPosition `json:"rhythmPos"`
+}
+
+// UnmarshalJSON decodes the controller info and fills the deprecated Rythm field
+func (c *ControllerInfo) UnmarshalJSON(data []byte) error {
+	type plain ControllerInfo
+
+	if err := json.Unmarshal(data, (*plain)(c)); err != nil {
+		return err
+	}
+
+	c.Rythm = c.Rhythm
+	return nil
 }
 
 // NewNanoleaf created a
@@ -84,6 +116,8 @@ func NewNanoleaf(url string) *Nanoleaf {
 		url:    url,
 	}
 
+	n.client.SetTransport(&observingTransport{n: n, next: n.client.GetClient().Transport})
+
 	n.Auth = newNanoAuth(n)
 	n.Stream = newNanoStream(n)
 
@@ -96,8 +130,11 @@ func (n *Nanoleaf) SetToken(token string) {
 
 	n.Identity = newNanoIdentity(n)
 	n.Effects = newNanoEffects(n)
+	n.Events = newNanoEvents(n)
+	n.Plugins = newNanoPlugins(n)
 	n.State = newNanoState(n)
 	n.Layout = newNanoLayout(n)
+	n.Rhythm = newNanoRhythm(n)
 }
 
 // GetToken returns the current token
@@ -112,8 +149,13 @@ func (n *Nanoleaf) IsConnected() bool {
 
 // GetControllerInfo returns controllerInfo
 func (n *Nanoleaf) GetControllerInfo() (*ControllerInfo, error) {
+	return n.GetControllerInfoContext(context.Background())
+}
+
+// GetControllerInfoContext returns controllerInfo, the request is aborted once ctx is done
+func (n *Nanoleaf) GetControllerInfoContext(ctx context.Context) (*ControllerInfo, error) {
 	url := fmt.Sprintf("%s/%s", n.url, n.token)
-	resp, err := n.client.R().Get(url)
+	resp, err := n.client.R().SetContext(ctx).Get(url)
 
 	if err != nil {
 		return nil, err
@@ -135,3 +177,21 @@ func (n *Nanoleaf) GetControllerInfo() (*ControllerInfo, error) {
 
 	return &controllerInfo, nil
 }
+
+// Raw sends a request to path below the authorized api url (e.g. "state/on") and returns status code and body
+func (n *Nanoleaf) Raw(method, path string, body []byte) (int, []byte, error) {
+	url := fmt.Sprintf("%s/%s/%s", n.url, n.token, strings.TrimPrefix(path, "/"))
+	req := n.client.R()
+
+	if len(body) > 0 {
+		req = req.SetHeader("Content-Type", "application/json").SetBody(body)
+	}
+
+	resp, err := req.Execute(strings.ToUpper(method), url)
+
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return resp.StatusCode(), resp.Body(), nil
+}
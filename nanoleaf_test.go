@@ -0,0 +1,220 @@
+package nanoleaf
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+// serveFixture returns a client whose controller answers GET / with the given testdata file. The fixtures are
+// hand-written after the api documentation and known firmware quirks, see testdata/README.md
+func serveFixture(t *testing.T, name string) *Nanoleaf {
+	t.Helper()
+
+	body, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return serveInfo(t, body)
+}
+
+// serveInfo returns a client whose controller answers GET / with body
+func serveInfo(t *testing.T, body []byte) *Nanoleaf {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/token" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	n := NewNanoleaf(server.URL + "/api/v1")
+	n.SetToken("token")
+	return n
+}
+
+func TestGetControllerInfo(t *testing.T) {
+	tests := []struct {
+		fixture     string
+		model       string
+		firmware    string
+		on          bool
+		brightness  int
+		colorMode   string
+		effect      string
+		effects     int
+		panels      int
+		lightPanels int
+		orientation int
+		rhythm      RhythmInfo
+	}{
+		{
+			fixture:     "lightpanels.json",
+			model:       "NL22",
+			firmware:    "1.5.0",
+			on:          true,
+			brightness:  100,
+			colorMode:   "effect",
+			effect:      "Flames",
+			effects:     8,
+			panels:      3,
+			lightPanels: 3,
+			orientation: 120,
+			rhythm: RhythmInfo{
+				Connected:       true,
+				ID:              2,
+				HardwareVersion: "1.4",
+				FirmwareVersion: "1.0.0",
+				Mode:            RhythmModeMicrophone,
+				Pos:             RhythmPosition{X: 29, Y: 207, O: 180},
+			},
+		},
+		{
+			fixture:     "canvas.json",
+			model:       "NL29",
+			firmware:    "4.1.3",
+			brightness:  42,
+			colorMode:   "hs",
+			effect:      "*Solid*",
+			effects:     4,
+			panels:      4,
+			lightPanels: 4,
+			rhythm: RhythmInfo{
+				Connected:       true,
+				Active:          true,
+				ID:              25391,
+				HardwareVersion: "2.0",
+				FirmwareVersion: "2.4.3",
+				Mode:            RhythmModeMicrophone,
+				Pos:             RhythmPosition{X: 50, Y: 50},
+			},
+		},
+		{
+			fixture:     "shapes.json",
+			model:       "NL42",
+			firmware:    "7.1.3",
+			on:          true,
+			brightness:  75,
+			colorMode:   "effect",
+			effect:      "Sunset",
+			effects:     4,
+			panels:      3,
+			lightPanels: 2,
+			orientation: 60,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			info, err := serveFixture(t, tt.fixture).GetControllerInfo()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if info.Model != tt.model || info.FirmwareVersion != tt.firmware || info.Manufacturer != "Nanoleaf" {
+				t.Errorf("got model %q firmware %q manufacturer %q", info.Model, info.FirmwareVersion, info.Manufacturer)
+			}
+
+			s := info.State
+			if s.On.Value != tt.on || s.Brightness.Value != tt.brightness || s.Brightness.Max != 100 || s.ColorMode != tt.colorMode {
+				t.Errorf("got state %+v", s)
+			}
+
+			if info.Effects.Active != tt.effect || len(info.Effects.List) != tt.effects {
+				t.Errorf("got effects %+v", info.Effects)
+			}
+
+			layout := info.PanelLayout
+			if layout.Layout.Panels != tt.panels || len(layout.Layout.PositionData) != tt.panels {
+				t.Errorf("got %d panels and %d positions, want %d", layout.Layout.Panels, len(layout.Layout.PositionData), tt.panels)
+			}
+
+			lit := 0
+			for _, panel := range layout.Layout.PositionData {
+				if !panel.ShapeType.IsControllerUnit() {
+					lit++
+				}
+			}
+
+			if lit != tt.lightPanels {
+				t.Errorf("got %d light panels, want %d", lit, tt.lightPanels)
+			}
+
+			if layout.GlobalOrientation.Value != tt.orientation || layout.GlobalOrientation.Max != 360 {
+				t.Errorf("got global orientation %+v", layout.GlobalOrientation)
+			}
+
+			if info.Rhythm != tt.rhythm {
+				t.Errorf("got rhythm %+v, want %+v", info.Rhythm, tt.rhythm)
+			}
+
+			if info.Rythm != info.Rhythm {
+				t.Errorf("deprecated Rythm %+v differs from Rhythm %+v", info.Rythm, info.Rhythm)
+			}
+		})
+	}
+}
+
+func TestGetControllerInfoKeepsUndocumentedBlocks(t *testing.T) {
+	body := `{"name":"Shapes","discovery":{},"firmwareUpgrade":{"newFirmwareAvailable":true,"version":"9.0.0"},` +
+		`"schedules":{"count":2},"cloudHash":{"hash":"abc"}}`
+
+	info, err := serveInfo(t, []byte(body)).GetControllerInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  json.RawMessage
+		want string
+	}{
+		{name: "discovery", got: info.Discovery, want: `{}`},
+		{name: "firmwareUpgrade", got: info.FirmwareUpgrade, want: `{"newFirmwareAvailable":true,"version":"9.0.0"}`},
+		{name: "schedules", got: info.Schedules, want: `{"count":2}`},
+		{name: "cloudHash", got: info.CloudHash, want: `{"hash":"abc"}`},
+	}
+
+	for _, tt := range tests {
+		if string(tt.got) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestGetControllerInfoErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		err    error
+	}{
+		{status: http.StatusUnauthorized, err: ErrUnauthorized},
+		{status: http.StatusInternalServerError, err: ErrUnexpectedResponse},
+		{status: http.StatusOK, body: `{"rhythm": {"rhythmId": true}}`, err: ErrParsingJSON},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		}))
+
+		n := NewNanoleaf(server.URL)
+		n.SetToken("token")
+
+		if _, err := n.GetControllerInfo(); err != tt.err {
+			t.Errorf("status %d: got %v, want %v", tt.status, err, tt.err)
+		}
+
+		server.Close()
+	}
+}
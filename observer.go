@@ -0,0 +1,84 @@
+package nanoleaf
+
+import (
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"time"
+)
+
+// Observer is notified about every request sent to the api and every packet written to the stream.
+// Implementations have to be safe for concurrent use
+type Observer interface {
+	// ObserveRequest is called once the response headers arrived or the request failed without a response,
+	// in which case status is 0 and err the reason (timeouts, refused connections, cancelled contexts).
+	// endpoint is the path below the api url without the token, e.g. "state/brightness"
+	ObserveRequest(method, endpoint string, status int, duration time.Duration, err error)
+	// ObservePacket is called after every stream effect written, size is the length of the packet in bytes
+	ObservePacket(size int, err error)
+}
+
+// observerBox wraps observers so atomic.Value always stores the same type
+type observerBox struct {
+	Observer
+}
+
+// SetObserver installs o, nil removes the current observer
+func (n *Nanoleaf) SetObserver(o Observer) {
+	n.observer.Store(observerBox{o})
+}
+
+// getObserver returns the current observer or nil
+func (n *Nanoleaf) getObserver() Observer {
+	box, _ := n.observer.Load().(observerBox)
+	return box.Observer
+}
+
+// observingTransport reports every round trip of the client to the current observer
+type observingTransport struct {
+	n    *Nanoleaf
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *observingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	o := t.n.getObserver()
+	if o == nil {
+		return resp, err
+	}
+
+	status := 0
+	if err != nil {
+		// report the cause instead of the error of the transport wrapping it
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			err = ctxErr
+		}
+	} else {
+		status = resp.StatusCode
+	}
+
+	o.ObserveRequest(req.Method, t.n.endpointOf(req.URL.Path), status, time.Since(start), err)
+	return resp, err
+}
+
+// endpointOf strips the path of the api url and the token from path, the root of the api results in "/".
+// Every path below the api url except "new" starts with the token, so the token itself is never read
+func (n *Nanoleaf) endpointOf(path string) string {
+	if base, err := neturl.Parse(n.url); err == nil {
+		path = strings.TrimPrefix(path, strings.TrimSuffix(base.Path, "/"))
+	}
+
+	path = strings.TrimPrefix(path, "/")
+	if path == "new" {
+		return path
+	}
+
+	if i := strings.Index(path, "/"); i >= 0 && path[i+1:] != "" {
+		return path[i+1:]
+	}
+
+	return "/"
+}
@@ -0,0 +1,292 @@
+package nanoleaf
+
+import (
+	"context"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultFrameDelay is used for gif frames without a delay
+const defaultFrameDelay = 100 * time.Millisecond
+
+// Animation is a sequence of images with their display duration
+type Animation struct {
+	Frames []image.Image
+	Delays []time.Duration
+}
+
+// Player streams an animation onto the panels
+type Player struct {
+	mu      sync.Mutex
+	stream  *NanoStream
+	effects []StreamEffect
+	delays  []time.Duration
+	index   int
+	loop    bool
+	paused  bool
+	speed   float64
+	wake    chan struct{}
+}
+
+// DecodeGIF decodes an animated gif into fully composed frames
+func DecodeGIF(r io.Reader) (*Animation, error) {
+	g, err := gif.DecodeAll(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(g.Image) == 0 {
+		return nil, ErrEmptyAnimation
+	}
+
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		bounds = g.Image[0].Bounds()
+	}
+
+	canvas := image.NewRGBA(bounds)
+	anim := &Animation{}
+
+	for i, frame := range g.Image {
+		var previous *image.RGBA
+		if i < len(g.Disposal) && g.Disposal[i] == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			draw.Draw(previous, bounds, canvas, bounds.Min, draw.Src)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		composed := image.NewRGBA(bounds)
+		draw.Draw(composed, bounds, canvas, bounds.Min, draw.Src)
+		anim.Frames = append(anim.Frames, composed)
+
+		delay := defaultFrameDelay
+		if i < len(g.Delay) && g.Delay[i] > 0 {
+			delay = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+		anim.Delays = append(anim.Delays, delay)
+
+		if i < len(g.Disposal) {
+			switch g.Disposal[i] {
+			case gif.DisposalBackground:
+				draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+			case gif.DisposalPrevious:
+				draw.Draw(canvas, bounds, previous, bounds.Min, draw.Src)
+			}
+		}
+	}
+
+	return anim, nil
+}
+
+// LoadImageSequence loads all png files of dir in lexical order, each shown for delay
+func LoadImageSequence(dir string, delay time.Duration) (*Animation, error) {
+	files, err := ioutil.ReadDir(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, file := range files {
+		if !file.IsDir() && strings.EqualFold(filepath.Ext(file.Name()), ".png") {
+			names = append(names, file.Name())
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, ErrEmptyAnimation
+	}
+
+	sort.Strings(names)
+
+	anim := &Animation{}
+	for _, name := range names {
+		f, err := os.Open(filepath.Join(dir, name))
+
+		if err != nil {
+			return nil, err
+		}
+
+		img, err := png.Decode(f)
+		f.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		anim.Frames = append(anim.Frames, img)
+		anim.Delays = append(anim.Delays, delay)
+	}
+
+	return anim, nil
+}
+
+// NewPlayer samples every frame of anim onto the layout of sampler and returns a player writing to stream
+func NewPlayer(stream *NanoStream, sampler *ImageSampler, anim *Animation) (*Player, error) {
+	if len(anim.Frames) == 0 || len(anim.Frames) != len(anim.Delays) {
+		return nil, ErrEmptyAnimation
+	}
+
+	p := &Player{
+		stream: stream,
+		loop:   true,
+		speed:  1,
+		wake:   make(chan struct{}, 1),
+	}
+
+	for i, frame := range anim.Frames {
+		p.effects = append(p.effects, sampler.StreamEffect(frame, 0))
+		p.delays = append(p.delays, anim.Delays[i])
+	}
+
+	return p, nil
+}
+
+// Play streams the animation until it ends, ctx is cancelled or a write fails
+func (p *Player) Play(ctx context.Context) error {
+	for {
+		p.mu.Lock()
+		paused := p.paused
+		index := p.index
+		p.mu.Unlock()
+
+		if paused {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-p.wake:
+			}
+
+			continue
+		}
+
+		if err := p.stream.WriteEffect(p.effects[index]); err != nil {
+			return err
+		}
+
+		p.mu.Lock()
+		delay := time.Duration(float64(p.delays[index]) / p.speed)
+		p.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-p.wake:
+			// seeked or paused, continue with the current frame right away
+			timer.Stop()
+			continue
+		case <-timer.C:
+		}
+
+		p.mu.Lock()
+		if p.index == index {
+			p.index++
+		}
+
+		if p.index >= len(p.effects) {
+			if !p.loop {
+				p.index = len(p.effects) - 1
+				p.mu.Unlock()
+				return nil
+			}
+
+			p.index = 0
+		}
+		p.mu.Unlock()
+	}
+}
+
+// Pause pauses playback at the current frame
+func (p *Player) Pause() {
+	p.mu.Lock()
+	p.paused = true
+	p.mu.Unlock()
+
+	p.notify()
+}
+
+// Resume resumes a paused playback
+func (p *Player) Resume() {
+	p.mu.Lock()
+	p.paused = false
+	p.mu.Unlock()
+
+	p.notify()
+}
+
+// IsPaused checks if playback is paused
+func (p *Player) IsPaused() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.paused
+}
+
+// Seek jumps to the given frame
+func (p *Player) Seek(frame int) error {
+	p.mu.Lock()
+	if frame < 0 || frame >= len(p.effects) {
+		p.mu.Unlock()
+		return ErrFrameOutOfRange
+	}
+
+	p.index = frame
+	p.mu.Unlock()
+
+	p.notify()
+	return nil
+}
+
+// Frame returns the index of the current frame
+func (p *Player) Frame() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.index
+}
+
+// Frames returns the number of frames
+func (p *Player) Frames() int {
+	return len(p.effects)
+}
+
+// SetSpeed sets the playback speed, 1 being the speed of the animation. Values <= 0 are ignored
+func (p *Player) SetSpeed(speed float64) {
+	if speed <= 0 {
+		return
+	}
+
+	p.mu.Lock()
+	p.speed = speed
+	p.mu.Unlock()
+}
+
+// SetLoop sets if the animation restarts after the last frame
+func (p *Player) SetLoop(loop bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.loop = loop
+}
+
+// notify wakes up a waiting Play call
+func (p *Player) notify() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,268 @@
+package nanoleaf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Plugin types known by the firmware
+const (
+	PluginTypeColor  = "color"
+	PluginTypeRhythm = "rhythm"
+)
+
+// Plugin option types used in plugin option schemas
+const (
+	PluginOptionInt    = "int"
+	PluginOptionDouble = "double"
+	PluginOptionBool   = "bool"
+	PluginOptionString = "string"
+)
+
+// NanoPlugins represents nanoleafs plugin based effects
+type NanoPlugins struct {
+	nano     *Nanoleaf
+	endpoint string
+}
+
+// PluginOptionSchema describes an option accepted by a plugin
+type PluginOptionSchema struct {
+	Name    string        `json:"name"`
+	Type    string        `json:"type"`
+	Default interface{}   `json:"defaultValue"`
+	Min     *float64      `json:"minValue"`
+	Max     *float64      `json:"maxValue"`
+	Values  []interface{} `json:"values"`
+}
+
+// Plugin describes a plugin installed on the nanoleafs
+type Plugin struct {
+	UUID        string               `json:"uuid"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Type        string               `json:"type"`
+	Options     []PluginOptionSchema `json:"pluginOptions"`
+}
+
+// PluginOption is a single option passed to a plugin
+type PluginOption struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+}
+
+// PluginEffect describes an effect driven by a plugin
+type PluginEffect struct {
+	Name       string         `json:"animName"`
+	PluginUUID string         `json:"pluginUuid"`
+	PluginType string         `json:"pluginType"`
+	ColorType  string         `json:"colorType"`
+	Palette    Palette        `json:"palette"`
+	Options    []PluginOption `json:"pluginOptions"`
+}
+
+// newNanoPlugins returns a new NanoPlugins instance
+func newNanoPlugins(nano *Nanoleaf) *NanoPlugins {
+	return &NanoPlugins{
+		nano:     nano,
+		endpoint: fmt.Sprintf("%s/%s/effects", nano.url, nano.token),
+	}
+}
+
+// List lists all plugins available on the nanoleafs
+func (p *NanoPlugins) List() ([]Plugin, error) {
+	body := jsonPayload{"write": jsonPayload{"command": "requestPlugins"}}
+	resp, err := p.nano.client.R().SetHeader("Content-Type", "application/json").SetBody(body).Put(p.endpoint)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode() == http.StatusUnauthorized {
+		return nil, ErrUnauthorized
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return nil, ErrUnexpectedResponse
+	}
+
+	var res struct {
+		Plugins []Plugin `json:"plugins"`
+	}
+
+	if err := json.Unmarshal(resp.Body(), &res); err != nil {
+		return nil, ErrParsingJSON
+	}
+
+	return res.Plugins, nil
+}
+
+// Get returns the plugin with the given uuid
+func (p *NanoPlugins) Get(uuid string) (*Plugin, error) {
+	plugins, err := p.List()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range plugins {
+		if plugins[i].UUID == uuid {
+			return &plugins[i], nil
+		}
+	}
+
+	return nil, ErrPluginNotFound
+}
+
+// Display validates given plugin effect and displays it without saving
+func (p *NanoPlugins) Display(effect PluginEffect) error {
+	if err := p.validate(effect); err != nil {
+		return err
+	}
+
+	return p.nano.Effects.WriteRaw(effect.payload("display"))
+}
+
+// Add validates given plugin effect and saves it on the nanoleafs
+func (p *NanoPlugins) Add(effect PluginEffect) error {
+	if err := p.validate(effect); err != nil {
+		return err
+	}
+
+	return p.nano.Effects.WriteRaw(effect.payload("add"))
+}
+
+// validate checks the palette of effect and its options against the schema of the installed plugin
+func (p *NanoPlugins) validate(effect PluginEffect) error {
+	if err := effect.Palette.Validate(); err != nil {
+		return err
+	}
+
+	plugin, err := p.Get(effect.PluginUUID)
+
+	if err != nil {
+		return err
+	}
+
+	return plugin.validateOptions(effect.Options)
+}
+
+// NewEffect returns an effect of the plugin. Options are validated against the plugins option schema
+func (p Plugin) NewEffect(name string, palette Palette, options map[string]interface{}) (PluginEffect, error) {
+	effect := PluginEffect{
+		Name:       name,
+		PluginUUID: p.UUID,
+		PluginType: p.Type,
+		ColorType:  "HSB",
+		Palette:    palette,
+		Options:    []PluginOption{},
+	}
+
+	for _, schema := range p.Options {
+		if value, ok := options[schema.Name]; ok {
+			effect.Options = append(effect.Options, PluginOption{Name: schema.Name, Value: value})
+		}
+	}
+
+	if len(effect.Options) != len(options) {
+		return effect, ErrInvalidPluginOption
+	}
+
+	return effect, p.validateOptions(effect.Options)
+}
+
+// validateOptions checks if every option is known by the plugin and matches its schema
+func (p Plugin) validateOptions(options []PluginOption) error {
+	for _, o := range options {
+		schema := p.option(o.Name)
+
+		if schema == nil || !schema.accepts(o.Value) {
+			return ErrInvalidPluginOption
+		}
+	}
+
+	return nil
+}
+
+// option returns the option schema of given name
+func (p Plugin) option(name string) *PluginOptionSchema {
+	for i := range p.Options {
+		if p.Options[i].Name == name {
+			return &p.Options[i]
+		}
+	}
+
+	return nil
+}
+
+// accepts checks if value matches type, range and allowed values of the schema
+func (s PluginOptionSchema) accepts(value interface{}) bool {
+	switch s.Type {
+	case PluginOptionInt, PluginOptionDouble:
+		number, ok := toFloat(value)
+
+		if !ok || (s.Type == PluginOptionInt && number != float64(int64(number))) {
+			return false
+		}
+
+		if (s.Min != nil && number < *s.Min) || (s.Max != nil && number > *s.Max) {
+			return false
+		}
+	case PluginOptionBool:
+		if _, ok := value.(bool); !ok {
+			return false
+		}
+	case PluginOptionString:
+		if _, ok := value.(string); !ok {
+			return false
+		}
+	default:
+		return false
+	}
+
+	if len(s.Values) == 0 {
+		return true
+	}
+
+	for _, allowed := range s.Values {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// payload returns the write payload of the effect for the given command
+func (e PluginEffect) payload(command string) jsonPayload {
+	return jsonPayload{
+		"write": jsonPayload{
+			"command":       command,
+			"animName":      e.Name,
+			"animType":      "plugin",
+			"colorType":     e.ColorType,
+			"palette":       e.Palette,
+			"pluginType":    e.PluginType,
+			"pluginUuid":    e.PluginUUID,
+			"pluginOptions": e.Options,
+		},
+	}
+}
+
+// toFloat converts numeric values into float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+
+	return 0, false
+}
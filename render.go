@@ -0,0 +1,375 @@
+package nanoleaf
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// render defaults
+const (
+	defaultRenderScale   = 1.0
+	defaultRenderPadding = 20.0
+	lineWidth            = 8.0
+	controllerRadius     = 6.0
+)
+
+var (
+	unlitColor      = color.RGBA{R: 0x33, G: 0x33, B: 0x33, A: 0xff}
+	outlineColor    = color.RGBA{R: 0x99, G: 0x99, B: 0x99, A: 0xff}
+	controllerColor = color.RGBA{R: 0x66, G: 0x66, B: 0x66, A: 0xff}
+)
+
+// digits 3x5 bitmap font used to print panel ids onto images
+var digits = [10][5]string{
+	{"###", "#.#", "#.#", "#.#", "###"},
+	{".#.", "##.", ".#.", ".#.", "###"},
+	{"###", "..#", "###", "#..", "###"},
+	{"###", "..#", "###", "..#", "###"},
+	{"#.#", "#.#", "###", "..#", "..#"},
+	{"###", "#..", "###", "..#", "###"},
+	{"###", "#..", "###", "#.#", "###"},
+	{"###", "..#", "..#", "..#", "..#"},
+	{"###", "#.#", "###", "#.#", "###"},
+	{"###", "#.#", "###", "..#", "###"},
+}
+
+// RenderOptions options used when rendering a layout
+type RenderOptions struct {
+	// Colors of the panels, panels without color are drawn unlit
+	Colors map[int]color.RGBA
+	// ShowIDs prints the panel ids onto the panels
+	ShowIDs bool
+	// Scale pixels per layout unit, defaults to 1
+	Scale float64
+	// Padding around the layout in layout units, DefaultRenderOptions uses 20
+	Padding float64
+	// Background color, transparent if nil
+	Background color.Color
+}
+
+// DefaultRenderOptions returns options rendering unlit panels at scale 1 with a padding of 20
+func DefaultRenderOptions() RenderOptions {
+	return RenderOptions{Scale: defaultRenderScale, Padding: defaultRenderPadding}
+}
+
+// ColorsFromEffect returns the color of the first frame of every panel of effect
+func ColorsFromEffect(effect StreamEffect) map[int]color.RGBA {
+	colors := make(map[int]color.RGBA, len(effect.Panels))
+
+	for _, panel := range effect.Panels {
+		if len(panel.Frames) == 0 {
+			continue
+		}
+
+		frame := panel.Frames[0]
+		colors[panel.ID] = color.RGBA{R: uint8(frame.Red), G: uint8(frame.Green), B: uint8(frame.Blue), A: 0xff}
+	}
+
+	return colors
+}
+
+// Colors returns the current color of every panel
+func (f *Framebuffer) Colors() map[int]color.RGBA {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	colors := make(map[int]color.RGBA, len(f.colors))
+	for id, c := range f.colors {
+		colors[id] = c
+	}
+
+	return colors
+}
+
+// SVG writes the layout as svg
+func (g LayoutGeometry) SVG(w io.Writer, opts RenderOptions) error {
+	opts = opts.withDefaults()
+	view := g.view(opts)
+	width, height := view.size()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", width, height, width, height)
+
+	if opts.Background != nil {
+		fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", hexColor(opts.Background))
+	}
+
+	for _, panel := range g.Panels {
+		center := view.project(panel.Center)
+		fill := opts.panelColor(panel.ID)
+
+		switch {
+		case panel.Shape.IsControllerUnit() || len(panel.Vertices) == 0:
+			fmt.Fprintf(&b, `<circle data-id="%d" cx="%.2f" cy="%.2f" r="%.2f" fill="%s"/>`+"\n",
+				panel.ID, center.X, center.Y, controllerRadius*opts.Scale, hexColor(controllerColor))
+			continue
+		case len(panel.Vertices) == 2:
+			a, c := view.project(panel.Vertices[0]), view.project(panel.Vertices[1])
+			fmt.Fprintf(&b, `<line data-id="%d" data-orientation="%.0f" x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s" stroke-width="%.2f" stroke-linecap="round"/>`+"\n",
+				panel.ID, panel.Rotation, a.X, a.Y, c.X, c.Y, hexColor(fill), lineWidth*opts.Scale)
+		default:
+			points := make([]string, len(panel.Vertices))
+			for i, v := range panel.Vertices {
+				p := view.project(v)
+				points[i] = fmt.Sprintf("%.2f,%.2f", p.X, p.Y)
+			}
+
+			fmt.Fprintf(&b, `<polygon data-id="%d" data-orientation="%.0f" points="%s" fill="%s" stroke="%s" stroke-width="%.2f"/>`+"\n",
+				panel.ID, panel.Rotation, strings.Join(points, " "), hexColor(fill), hexColor(outlineColor), opts.Scale)
+
+			// orientation marker pointing from the center towards the first vertex
+			marker := view.project(lerp(panel.Center, panel.Vertices[0], 0.35))
+			fmt.Fprintf(&b, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s" stroke-width="%.2f"/>`+"\n",
+				center.X, center.Y, marker.X, marker.Y, hexColor(outlineColor), opts.Scale)
+		}
+
+		if opts.ShowIDs {
+			fmt.Fprintf(&b, `<text x="%.2f" y="%.2f" font-family="monospace" font-size="%.2f" text-anchor="middle" dominant-baseline="middle" fill="%s">%d</text>`+"\n",
+				center.X, center.Y, 12*opts.Scale, hexColor(contrastColor(fill)), panel.ID)
+		}
+	}
+
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Image renders the layout into an image
+func (g LayoutGeometry) Image(opts RenderOptions) *image.RGBA {
+	opts = opts.withDefaults()
+	view := g.view(opts)
+	width, height := view.size()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	if opts.Background != nil {
+		fillRect(img, img.Bounds(), toRGBA(opts.Background))
+	}
+
+	for _, panel := range g.Panels {
+		center := view.project(panel.Center)
+		fill := opts.panelColor(panel.ID)
+
+		switch {
+		case panel.Shape.IsControllerUnit() || len(panel.Vertices) == 0:
+			fillCircle(img, center, controllerRadius*opts.Scale, controllerColor)
+			continue
+		case len(panel.Vertices) == 2:
+			fillSegment(img, view.project(panel.Vertices[0]), view.project(panel.Vertices[1]), lineWidth*opts.Scale, fill)
+		default:
+			vertices := make([]Point, len(panel.Vertices))
+			for i, v := range panel.Vertices {
+				vertices[i] = view.project(v)
+			}
+
+			fillPolygon(img, vertices, fill)
+			for i := range vertices {
+				fillSegment(img, vertices[i], vertices[(i+1)%len(vertices)], opts.Scale, outlineColor)
+			}
+
+			fillSegment(img, center, view.project(lerp(panel.Center, panel.Vertices[0], 0.35)), opts.Scale, outlineColor)
+		}
+
+		if opts.ShowIDs {
+			drawNumber(img, center, panel.ID, int(math.Max(1, math.Round(2*opts.Scale))), contrastColor(fill))
+		}
+	}
+
+	return img
+}
+
+// PNG writes the layout as png
+func (g LayoutGeometry) PNG(w io.Writer, opts RenderOptions) error {
+	return png.Encode(w, g.Image(opts))
+}
+
+// withDefaults replaces options which cannot be rendered
+func (o RenderOptions) withDefaults() RenderOptions {
+	if o.Scale <= 0 {
+		o.Scale = defaultRenderScale
+	}
+
+	if o.Padding < 0 {
+		o.Padding = 0
+	}
+
+	return o
+}
+
+// panelColor returns the color of a panel or the unlit color
+func (o RenderOptions) panelColor(id int) color.RGBA {
+	if c, ok := o.Colors[id]; ok {
+		return c
+	}
+
+	return unlitColor
+}
+
+// renderView maps layout space onto image space
+type renderView struct {
+	bounds Rect
+	scale  float64
+}
+
+// view returns the view of the layout including padding
+func (g LayoutGeometry) view(opts RenderOptions) renderView {
+	bounds := g.BoundingBox()
+	bounds.Min.X -= opts.Padding
+	bounds.Min.Y -= opts.Padding
+	bounds.Max.X += opts.Padding
+	bounds.Max.Y += opts.Padding
+
+	return renderView{bounds: bounds, scale: opts.Scale}
+}
+
+// size returns the image size in pixels
+func (v renderView) size() (int, int) {
+	return int(math.Ceil(v.bounds.Width() * v.scale)), int(math.Ceil(v.bounds.Height() * v.scale))
+}
+
+// project converts a point from layout space (y up) into image space (y down)
+func (v renderView) project(p Point) Point {
+	return Point{
+		X: (p.X - v.bounds.Min.X) * v.scale,
+		Y: (v.bounds.Max.Y - p.Y) * v.scale,
+	}
+}
+
+// fillRect fills rect with c
+func fillRect(img *image.RGBA, rect image.Rectangle, c color.RGBA) {
+	rect = rect.Intersect(img.Bounds())
+
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// fillPolygon fills the polygon using the even odd rule
+func fillPolygon(img *image.RGBA, vertices []Point, c color.RGBA) {
+	bounds := pixelBounds(vertices, 0).Intersect(img.Bounds())
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if insidePolygon(Point{X: float64(x) + 0.5, Y: float64(y) + 0.5}, vertices) {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// fillSegment draws a line of the given width
+func fillSegment(img *image.RGBA, a, b Point, width float64, c color.RGBA) {
+	bounds := pixelBounds([]Point{a, b}, width/2+1).Intersect(img.Bounds())
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if segmentDistance(Point{X: float64(x) + 0.5, Y: float64(y) + 0.5}, a, b) <= width/2 {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// fillCircle draws a filled circle
+func fillCircle(img *image.RGBA, center Point, radius float64, c color.RGBA) {
+	fillSegment(img, center, center, radius*2, c)
+}
+
+// drawNumber prints n centered at center using the bitmap font
+func drawNumber(img *image.RGBA, center Point, n int, size int, c color.RGBA) {
+	text := strconv.Itoa(n)
+	width := (len(text)*4 - 1) * size
+	x0 := int(center.X) - width/2
+	y0 := int(center.Y) - 5*size/2
+
+	for i, r := range text {
+		if r < '0' || r > '9' {
+			continue
+		}
+
+		glyph := digits[r-'0']
+		for row, line := range glyph {
+			for col, pixel := range line {
+				if pixel != '#' {
+					continue
+				}
+
+				x := x0 + (i*4+col)*size
+				y := y0 + row*size
+				fillRect(img, image.Rect(x, y, x+size, y+size), c)
+			}
+		}
+	}
+}
+
+// pixelBounds returns the pixel rectangle covering points grown by margin
+func pixelBounds(points []Point, margin float64) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+
+	for _, p := range points {
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+		minY, maxY = math.Min(minY, p.Y), math.Max(maxY, p.Y)
+	}
+
+	return image.Rect(
+		int(math.Floor(minX-margin)), int(math.Floor(minY-margin)),
+		int(math.Ceil(maxX+margin)), int(math.Ceil(maxY+margin)),
+	)
+}
+
+// insidePolygon checks if p is inside the polygon using the even odd rule
+func insidePolygon(p Point, vertices []Point) bool {
+	inside := false
+
+	for i, j := 0, len(vertices)-1; i < len(vertices); j, i = i, i+1 {
+		a, b := vertices[i], vertices[j]
+
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// segmentDistance returns the distance of p to the segment a b
+func segmentDistance(p, a, b Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	length := dx*dx + dy*dy
+
+	if length == 0 {
+		return distance(p, a)
+	}
+
+	t := math.Max(0, math.Min(1, ((p.X-a.X)*dx+(p.Y-a.Y)*dy)/length))
+	return distance(p, Point{X: a.X + t*dx, Y: a.Y + t*dy})
+}
+
+// lerp returns the point at t between a and b
+func lerp(a, b Point, t float64) Point {
+	return Point{X: a.X + (b.X-a.X)*t, Y: a.Y + (b.Y-a.Y)*t}
+}
+
+// hexColor returns c as #rrggbb
+func hexColor(c color.Color) string {
+	rgba := toRGBA(c)
+	return fmt.Sprintf("#%02x%02x%02x", rgba.R, rgba.G, rgba.B)
+}
+
+// contrastColor returns black or white, whichever is more readable on c
+func contrastColor(c color.RGBA) color.RGBA {
+	if 0.299*float64(c.R)+0.587*float64(c.G)+0.114*float64(c.B) > 140 {
+		return color.RGBA{A: 0xff}
+	}
+
+	return color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+}
@@ -0,0 +1,203 @@
+package nanoleaf
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultRendererFPS is used if a renderer is created without a valid fps
+const DefaultRendererFPS = 30
+
+// RendererStats counters describing the work done by a renderer
+type RendererStats struct {
+	Submitted uint64 `json:"submitted"`
+	Coalesced uint64 `json:"coalesced"`
+	Dropped   uint64 `json:"dropped"`
+	Packets   uint64 `json:"packets"`
+	Errors    uint64 `json:"errors"`
+}
+
+// Renderer sends panel updates of many goroutines as one packet per tick
+type Renderer struct {
+	mu       sync.Mutex
+	stream   *NanoStream
+	interval time.Duration
+	pending  map[int]PanelEffect
+	sources  map[int]*Framebuffer
+	order    []int
+	stats    RendererStats
+	lastErr  error
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewRenderer returns a new renderer writing to stream at the given fps
+func NewRenderer(stream *NanoStream, fps int) *Renderer {
+	if fps <= 0 {
+		fps = DefaultRendererFPS
+	}
+
+	return &Renderer{
+		stream:   stream,
+		interval: time.Second / time.Duration(fps),
+		pending:  map[int]PanelEffect{},
+		sources:  map[int]*Framebuffer{},
+	}
+}
+
+// Start connects the stream if necessary and starts sending packets
+func (r *Renderer) Start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stop != nil {
+		return ErrRendererRunning
+	}
+
+	if !r.stream.IsConnected() {
+		if err := r.stream.Connect(); err != nil {
+			return err
+		}
+	}
+
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go r.run(r.stop, r.done)
+	return nil
+}
+
+// Stop stops sending packets, drops pending updates and closes the connection
+func (r *Renderer) Stop() error {
+	r.mu.Lock()
+	stop, done := r.stop, r.done
+	r.stop, r.done = nil, nil
+	r.mu.Unlock()
+
+	if stop == nil {
+		return nil
+	}
+
+	close(stop)
+	<-done
+
+	r.mu.Lock()
+	r.stats.Dropped += uint64(len(r.pending))
+	sources := r.sources
+	r.pending = map[int]PanelEffect{}
+	r.sources = map[int]*Framebuffer{}
+	r.order = nil
+	r.mu.Unlock()
+
+	restoreSources(sources)
+
+	return r.stream.Disconnect()
+}
+
+// Submit queues the panels of effect for the next tick.
+// A panel queued twice within one tick only sends its latest update
+func (r *Renderer) Submit(effect StreamEffect) {
+	r.submit(effect, nil)
+}
+
+// submit queues the panels of effect and remembers the framebuffer they were flushed from, if any
+func (r *Renderer) submit(effect StreamEffect, source *Framebuffer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, panel := range effect.Panels {
+		r.stats.Submitted++
+
+		if _, ok := r.pending[panel.ID]; ok {
+			r.stats.Coalesced++
+		} else {
+			r.order = append(r.order, panel.ID)
+		}
+
+		r.pending[panel.ID] = panel
+
+		if source != nil {
+			r.sources[panel.ID] = source
+		} else {
+			delete(r.sources, panel.ID)
+		}
+	}
+}
+
+// Stats returns a snapshot of the renderers counters
+func (r *Renderer) Stats() RendererStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.stats
+}
+
+// Err returns the last error that occurred while writing a packet
+func (r *Renderer) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.lastErr
+}
+
+// run flushes pending updates every interval until stop is closed
+func (r *Renderer) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			r.flush()
+		}
+	}
+}
+
+// flush writes all pending updates as a single packet
+func (r *Renderer) flush() {
+	r.mu.Lock()
+	if len(r.order) == 0 {
+		r.mu.Unlock()
+		return
+	}
+
+	effect := StreamEffect{Panels: make([]PanelEffect, 0, len(r.order))}
+	for _, id := range r.order {
+		effect.Panels = append(effect.Panels, r.pending[id])
+	}
+
+	sources := r.sources
+	r.pending = map[int]PanelEffect{}
+	r.sources = map[int]*Framebuffer{}
+	r.order = nil
+	r.mu.Unlock()
+
+	err := r.stream.WriteEffect(effect)
+
+	if err != nil {
+		restoreSources(sources)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err != nil {
+		r.stats.Errors++
+		r.stats.Dropped += uint64(len(effect.Panels))
+		r.lastErr = err
+		return
+	}
+
+	r.stats.Packets++
+}
+
+// restoreSources marks unsent panels as changed in the framebuffers they were flushed from
+func restoreSources(sources map[int]*Framebuffer) {
+	for id, fb := range sources {
+		fb.markDirty(id)
+	}
+}
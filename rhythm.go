@@ -0,0 +1,175 @@
+package nanoleaf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// RhythmMode input used by the rhythm module
+type RhythmMode int
+
+// Rhythm modes
+const (
+	RhythmModeMicrophone RhythmMode = 0
+	RhythmModeAux        RhythmMode = 1
+)
+
+// String returns a readable name of the mode
+func (m RhythmMode) String() string {
+	switch m {
+	case RhythmModeMicrophone:
+		return "microphone"
+	case RhythmModeAux:
+		return "aux"
+	}
+
+	return "unknown"
+}
+
+// NanoRhythm rhythm module
+type NanoRhythm struct {
+	nano     *Nanoleaf
+	endpoint string
+}
+
+// newNanoRhythm returns a new instance of NanoRhythm
+func newNanoRhythm(nano *Nanoleaf) *NanoRhythm {
+	return &NanoRhythm{
+		nano:     nano,
+		endpoint: fmt.Sprintf("%s/%s/rhythm", nano.url, nano.token),
+	}
+}
+
+// Get returns everything known about the rhythm module
+func (r *NanoRhythm) Get() (RhythmInfo, error) {
+	var info RhythmInfo
+	err := r.get("", &info)
+
+	return info, err
+}
+
+// IsConnected checks if a rhythm module is attached
+func (r *NanoRhythm) IsConnected() (bool, error) {
+	var connected bool
+	err := r.get("/rhythmConnected", &connected)
+
+	return connected, err
+}
+
+// IsActive checks if the rhythm module is currently driving an effect
+func (r *NanoRhythm) IsActive() (bool, error) {
+	if err := r.requireConnected(); err != nil {
+		return false, err
+	}
+
+	var active bool
+	err := r.get("/rhythmActive", &active)
+
+	return active, err
+}
+
+// GetMode returns the input used by the rhythm module
+func (r *NanoRhythm) GetMode() (RhythmMode, error) {
+	if err := r.requireConnected(); err != nil {
+		return RhythmModeMicrophone, err
+	}
+
+	var mode RhythmMode
+	err := r.get("/rhythmMode", &mode)
+
+	return mode, err
+}
+
+// SetMode switches the rhythm module between microphone and aux input
+func (r *NanoRhythm) SetMode(mode RhythmMode) error {
+	if mode != RhythmModeMicrophone && mode != RhythmModeAux {
+		return ErrInvalidRhythmMode
+	}
+
+	info, err := r.Get()
+
+	if err != nil {
+		return err
+	}
+
+	if !info.Connected {
+		return ErrRhythmNotConnected
+	}
+
+	if mode == RhythmModeAux && !info.AuxAvailable {
+		return ErrAuxNotAvailable
+	}
+
+	body := jsonPayload{"rhythmMode": mode}
+	resp, err := r.nano.client.R().SetHeader("Content-Type", "application/json").SetBody(body).Put(r.endpoint)
+
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+
+	if resp.StatusCode() != http.StatusNoContent {
+		return ErrUnexpectedResponse
+	}
+
+	return nil
+}
+
+// GetPosition returns the position of the rhythm module in layout space
+func (r *NanoRhythm) GetPosition() (RhythmPosition, error) {
+	var pos RhythmPosition
+
+	if err := r.requireConnected(); err != nil {
+		return pos, err
+	}
+
+	err := r.get("/rhythmPos", &pos)
+	return pos, err
+}
+
+// requireConnected returns ErrRhythmNotConnected if no rhythm module is attached
+func (r *NanoRhythm) requireConnected() error {
+	connected, err := r.IsConnected()
+
+	if err != nil {
+		return err
+	}
+
+	if !connected {
+		return ErrRhythmNotConnected
+	}
+
+	return nil
+}
+
+// get requests the given path below the rhythm endpoint and parses the response into v
+func (r *NanoRhythm) get(path string, v interface{}) error {
+	url := fmt.Sprintf("%s%s", r.endpoint, path)
+	resp, err := r.nano.client.R().Get(url)
+
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode() == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+
+	if resp.StatusCode() == http.StatusNotFound {
+		return ErrRhythmNotConnected
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		return ErrUnexpectedResponse
+	}
+
+	if err := json.Unmarshal(resp.Body(), v); err != nil {
+		return ErrParsingJSON
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package nanoleaf
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// maxSamplesPerAxis limits the number of pixels read per panel and axis
+const maxSamplesPerAxis = 16
+
+// ImageSampler maps images onto the physical panel layout
+type ImageSampler struct {
+	ids         []int
+	positions   map[int]Point
+	sideLengths map[int]float64
+	min         Point
+	max         Point
+}
+
+// NewImageSampler returns a sampler for the light emitting panels of layout rotated by the given global
+// orientation. Controller units are skipped
+func NewImageSampler(layout *PanelLayout, orientation int) *ImageSampler {
+	s := &ImageSampler{
+		positions:   layout.OrientedPositions(orientation),
+		sideLengths: map[int]float64{},
+		min:         Point{X: math.Inf(1), Y: math.Inf(1)},
+		max:         Point{X: math.Inf(-1), Y: math.Inf(-1)},
+	}
+
+	for _, panel := range layout.PositionData {
+		if panel.ShapeType.IsControllerUnit() {
+			continue
+		}
+
+		// old firmware only reports the side length of the whole layout
+		sideLength := panel.ShapeType.SideLength()
+		if panel.ShapeType == ShapeTriangle && layout.SideLength > 0 {
+			sideLength = float64(layout.SideLength)
+		}
+
+		p := s.positions[panel.ID]
+		half := sideLength / 2
+		s.sideLengths[panel.ID] = sideLength
+		s.ids = append(s.ids, panel.ID)
+		s.min.X, s.max.X = math.Min(s.min.X, p.X-half), math.Max(s.max.X, p.X+half)
+		s.min.Y, s.max.Y = math.Min(s.min.Y, p.Y-half), math.Max(s.max.Y, p.Y+half)
+	}
+
+	return s
+}
+
+// NewImageSampler fetches layout and global orientation and returns a sampler for them
+func (l *NanoLayout) NewImageSampler() (*ImageSampler, error) {
+	layout, err := l.GetLayout()
+
+	if err != nil {
+		return nil, err
+	}
+
+	orientation, err := l.GetGlobalOrientation()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewImageSampler(layout, orientation.Value), nil
+}
+
+// Sample stretches img over the layout and returns the average color of the area covered by every panel
+func (s *ImageSampler) Sample(img image.Image) map[int]color.RGBA {
+	colors := make(map[int]color.RGBA, len(s.ids))
+	bounds := img.Bounds()
+
+	if len(s.ids) == 0 || bounds.Empty() {
+		return colors
+	}
+
+	width, height := s.max.X-s.min.X, s.max.Y-s.min.Y
+	if width <= 0 {
+		width = 1
+	}
+
+	if height <= 0 {
+		height = 1
+	}
+
+	scaleX := float64(bounds.Dx()) / width
+	scaleY := float64(bounds.Dy()) / height
+
+	// sample the square of half the side length around the panels center
+	for _, id := range s.ids {
+		p := s.positions[id]
+		radius := s.sideLengths[id] / 4
+
+		// layout space grows upwards, image space downwards
+		x0 := bounds.Min.X + int((p.X-radius-s.min.X)*scaleX)
+		x1 := bounds.Min.X + int(math.Ceil((p.X+radius-s.min.X)*scaleX))
+		y0 := bounds.Min.Y + int((s.max.Y-p.Y-radius)*scaleY)
+		y1 := bounds.Min.Y + int(math.Ceil((s.max.Y-p.Y+radius)*scaleY))
+
+		colors[id] = averageColor(img, image.Rect(x0, y0, x1, y1).Intersect(bounds))
+	}
+
+	return colors
+}
+
+// StreamEffect samples img and returns a single frame effect for every panel
+func (s *ImageSampler) StreamEffect(img image.Image, transition int) StreamEffect {
+	colors := s.Sample(img)
+	effect := StreamEffect{Panels: make([]PanelEffect, 0, len(s.ids))}
+
+	for _, id := range s.ids {
+		c := colors[id]
+		effect.Panels = append(effect.Panels, PanelEffect{
+			ID: id,
+			Frames: []FrameEffect{
+				{Red: int(c.R), Green: int(c.G), Blue: int(c.B), Transition: transition},
+			},
+		})
+	}
+
+	return effect
+}
+
+// Draw samples img into the framebuffer. Panels unknown to fb are skipped
+func (s *ImageSampler) Draw(img image.Image, fb *Framebuffer) {
+	for id, c := range s.Sample(img) {
+		fb.Set(id, c)
+	}
+}
+
+// averageColor returns the average color of rect, reading at most maxSamplesPerAxis pixels per axis
+func averageColor(img image.Image, rect image.Rectangle) color.RGBA {
+	if rect.Empty() {
+		return color.RGBA{A: 0xff}
+	}
+
+	stepX := rect.Dx()/maxSamplesPerAxis + 1
+	stepY := rect.Dy()/maxSamplesPerAxis + 1
+
+	var r, g, b, n uint64
+	for y := rect.Min.Y; y < rect.Max.Y; y += stepY {
+		for x := rect.Min.X; x < rect.Max.X; x += stepX {
+			cr, cg, cb, _ := img.At(x, y).RGBA()
+			r, g, b, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), n+1
+		}
+	}
+
+	return color.RGBA{
+		R: uint8(r / n >> 8),
+		G: uint8(g / n >> 8),
+		B: uint8(b / n >> 8),
+		A: 0xff,
+	}
+}
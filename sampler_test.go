@@ -0,0 +1,83 @@
+package nanoleaf
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// hexagonLayout returns two hexagons side by side and their controller unit, reported with a side length of
+// 0 like Shapes controllers do
+func hexagonLayout() *PanelLayout {
+	return &PanelLayout{
+		Panels: 3,
+		PositionData: []PanelPositionData{
+			{ID: 8294, X: 0, Y: 0, ShapeType: ShapeHexagon},
+			{ID: 5023, X: 400, Y: 0, ShapeType: ShapeHexagon},
+			{ID: 0, X: 200, Y: -60, ShapeType: ShapeShapesController},
+		},
+	}
+}
+
+// stripes returns an image of alternating red and blue columns
+func stripes(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for x := 0; x < width; x++ {
+		c := color.RGBA{R: 0xff, A: 0xff}
+		if x%2 == 1 {
+			c = color.RGBA{B: 0xff, A: 0xff}
+		}
+
+		for y := 0; y < height; y++ {
+			img.Set(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func TestImageSamplerSkipsControllerUnits(t *testing.T) {
+	effect := NewImageSampler(hexagonLayout(), 0).StreamEffect(stripes(64, 64), 1)
+
+	ids := panelIDs(effect)
+	if len(ids) != 2 || ids[0] != 8294 || ids[1] != 5023 {
+		t.Errorf("got panels %v, want 8294 and 5023", ids)
+	}
+}
+
+func TestImageSamplerUsesShapeSideLength(t *testing.T) {
+	colors := NewImageSampler(hexagonLayout(), 0).Sample(stripes(64, 16))
+
+	for _, id := range []int{8294, 5023} {
+		c := colors[id]
+
+		// a sample area of a single pixel would be either red or blue
+		if c.R == 0 || c.B == 0 {
+			t.Errorf("panel %d: got %v, want a mix of the stripes", id, c)
+		}
+	}
+}
+
+func TestImageSamplerSplitsImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 10))
+	for x := 0; x < 100; x++ {
+		for y := 0; y < 10; y++ {
+			if x < 50 {
+				img.Set(x, y, color.RGBA{R: 0xff, A: 0xff})
+			} else {
+				img.Set(x, y, color.RGBA{G: 0xff, A: 0xff})
+			}
+		}
+	}
+
+	colors := NewImageSampler(hexagonLayout(), 0).Sample(img)
+
+	if c := colors[8294]; c != (color.RGBA{R: 0xff, A: 0xff}) {
+		t.Errorf("left panel: got %v, want red", c)
+	}
+
+	if c := colors[5023]; c != (color.RGBA{G: 0xff, A: 0xff}) {
+		t.Errorf("right panel: got %v, want green", c)
+	}
+}
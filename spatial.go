@@ -0,0 +1,174 @@
+package nanoleaf
+
+import (
+	"math"
+	"sort"
+)
+
+// positionEpsilon positions closer than this are considered equal, rotations leave rounding errors behind
+const positionEpsilon = 1e-6
+
+// positionedPanel a light emitting panel with its orientation corrected position
+type positionedPanel struct {
+	id int
+	p  Point
+}
+
+// Centroid returns the average position of all light emitting panels rotated by the global orientation
+func (p *PanelLayout) Centroid(orientation int) Point {
+	panels := p.positioned(orientation)
+	var c Point
+
+	if len(panels) == 0 {
+		return c
+	}
+
+	for _, panel := range panels {
+		c.X += panel.p.X
+		c.Y += panel.p.Y
+	}
+
+	return Point{X: c.X / float64(len(panels)), Y: c.Y / float64(len(panels))}
+}
+
+// Bounds returns the rectangle spanned by the panel positions rotated by the global orientation
+func (p *PanelLayout) Bounds(orientation int) Rect {
+	panels := p.positioned(orientation)
+
+	if len(panels) == 0 {
+		return Rect{}
+	}
+
+	r := Rect{Min: panels[0].p, Max: panels[0].p}
+	for _, panel := range panels[1:] {
+		r = r.Union(Rect{Min: panel.p, Max: panel.p})
+	}
+
+	return r
+}
+
+// SortLeftToRight returns the panel ids ordered by x, panels in the same column from top to bottom
+func (p *PanelLayout) SortLeftToRight(orientation int) []int {
+	return p.sorted(orientation, func(a, b Point) bool {
+		if !nearlyEqual(a.X, b.X) {
+			return a.X < b.X
+		}
+
+		return !nearlyEqual(a.Y, b.Y) && a.Y > b.Y
+	})
+}
+
+// SortTopToBottom returns the panel ids ordered by y, panels in the same row from left to right
+func (p *PanelLayout) SortTopToBottom(orientation int) []int {
+	return p.sorted(orientation, func(a, b Point) bool {
+		if !nearlyEqual(a.Y, b.Y) {
+			return a.Y > b.Y
+		}
+
+		return !nearlyEqual(a.X, b.X) && a.X < b.X
+	})
+}
+
+// SortByAngle returns the panel ids ordered clockwise around the centroid, starting at twelve o'clock
+func (p *PanelLayout) SortByAngle(orientation int) []int {
+	c := p.Centroid(orientation)
+
+	return p.sorted(orientation, func(a, b Point) bool {
+		x, y := clockAngle(c, a), clockAngle(c, b)
+		return !nearlyEqual(x, y) && x < y
+	})
+}
+
+// SortByDistance returns the panel ids ordered by their distance from point
+func (p *PanelLayout) SortByDistance(orientation int, point Point) []int {
+	return p.sorted(orientation, func(a, b Point) bool {
+		x, y := distance(a, point), distance(b, point)
+		return !nearlyEqual(x, y) && x < y
+	})
+}
+
+// SelectRect returns the ids of the panels whose position is inside rect
+func (p *PanelLayout) SelectRect(orientation int, rect Rect) []int {
+	return p.selected(orientation, rect.Contains)
+}
+
+// SelectCircle returns the ids of the panels whose position is within radius of center
+func (p *PanelLayout) SelectCircle(orientation int, center Point, radius float64) []int {
+	return p.selected(orientation, func(point Point) bool {
+		return distance(point, center) <= radius
+	})
+}
+
+// SelectPolygon returns the ids of the panels whose position is inside the polygon
+func (p *PanelLayout) SelectPolygon(orientation int, vertices []Point) []int {
+	return p.selected(orientation, func(point Point) bool {
+		return insidePolygon(point, vertices)
+	})
+}
+
+// positioned returns the light emitting panels with their orientation corrected positions ordered by id
+func (p *PanelLayout) positioned(orientation int) []positionedPanel {
+	positions := p.OrientedPositions(orientation)
+	panels := make([]positionedPanel, 0, len(positions))
+	seen := map[int]bool{}
+
+	for _, panel := range p.PositionData {
+		if panel.ShapeType.IsControllerUnit() || seen[panel.ID] {
+			continue
+		}
+
+		seen[panel.ID] = true
+		panels = append(panels, positionedPanel{id: panel.ID, p: positions[panel.ID]})
+	}
+
+	sort.Slice(panels, func(i, j int) bool {
+		return panels[i].id < panels[j].id
+	})
+
+	return panels
+}
+
+// sorted returns the panel ids ordered by less, ties are ordered by id
+func (p *PanelLayout) sorted(orientation int, less func(a, b Point) bool) []int {
+	panels := p.positioned(orientation)
+
+	sort.SliceStable(panels, func(i, j int) bool {
+		return less(panels[i].p, panels[j].p)
+	})
+
+	ids := make([]int, len(panels))
+	for i, panel := range panels {
+		ids[i] = panel.id
+	}
+
+	return ids
+}
+
+// selected returns the ids of the panels matching keep ordered by id
+func (p *PanelLayout) selected(orientation int, keep func(Point) bool) []int {
+	ids := []int{}
+
+	for _, panel := range p.positioned(orientation) {
+		if keep(panel.p) {
+			ids = append(ids, panel.id)
+		}
+	}
+
+	return ids
+}
+
+// nearlyEqual reports if a and b differ by less than positionEpsilon
+func nearlyEqual(a, b float64) bool {
+	return math.Abs(a-b) < positionEpsilon
+}
+
+// clockAngle returns the clockwise angle (0-2π) of p around center measured from twelve o'clock
+func clockAngle(center, p Point) float64 {
+	angle := math.Atan2(p.X-center.X, p.Y-center.Y)
+
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+
+	return angle
+}
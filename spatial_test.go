@@ -0,0 +1,53 @@
+package nanoleaf
+
+import (
+	"reflect"
+	"testing"
+)
+
+// rotatedSquares returns squares which end up in shared rows and columns apart from rounding errors once
+// rotated by 270 degrees
+func rotatedSquares() *PanelLayout {
+	return &PanelLayout{
+		Panels: 5,
+		PositionData: []PanelPositionData{
+			{ID: 3, X: 0, Y: 300, ShapeType: ShapeSquare},
+			{ID: 1, X: 100, Y: 0, ShapeType: ShapeSquare},
+			{ID: 2, X: 200, Y: 0, ShapeType: ShapeSquare},
+			{ID: 4, X: 100, Y: 100, ShapeType: ShapeSquare},
+			{ID: 5, X: 200, Y: 200, ShapeType: ShapeSquare},
+			{ID: 0, X: 150, Y: -50, ShapeType: ShapeShapesController},
+		},
+	}
+}
+
+func TestSortLeftToRight(t *testing.T) {
+	got := rotatedSquares().SortLeftToRight(270)
+
+	// panels 1 and 2 share the left column, 4 and 5 the middle one
+	if want := []int{1, 2, 4, 5, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSortTopToBottom(t *testing.T) {
+	got := rotatedSquares().SortTopToBottom(270)
+
+	// panels 1 and 4 share the second row, 2 and 5 the third one
+	if want := []int{3, 1, 4, 2, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSortByDistance(t *testing.T) {
+	l := &PanelLayout{PositionData: []PanelPositionData{
+		{ID: 2, X: 100, Y: 0, ShapeType: ShapeSquare},
+		{ID: 1, X: 0, Y: 100, ShapeType: ShapeSquare},
+		{ID: 3, X: 300, Y: 0, ShapeType: ShapeSquare},
+	}}
+
+	// 1 and 2 are equally far from the origin, ties are ordered by id
+	if got, want := l.SortByDistance(30, Point{}), []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
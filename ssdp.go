@@ -0,0 +1,202 @@
+package nanoleaf
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SSDP defaults
+const (
+	// SSDPAddress multicast address and port of SSDP
+	SSDPAddress = "239.255.255.250:1900"
+	// SSDPTargetAurora search target of Light Panels
+	SSDPTargetAurora = "nanoleaf_aurora:light"
+	// SSDPTargetCanvas search target of Canvas
+	SSDPTargetCanvas = "nanoleaf:nl29"
+)
+
+// ssdpModels model numbers of the ssdp search targets
+var ssdpModels = map[string]string{
+	SSDPTargetAurora: modelLightPanels,
+	SSDPTargetCanvas: "NL29",
+}
+
+// SSDPDiscoverer searches for controllers via SSDP M-SEARCH
+type SSDPDiscoverer struct {
+	// Address the search is sent to, defaults to SSDPAddress. Point it at a local responder for tests
+	Address string
+	// Targets searched for, defaults to SSDPTargetAurora and SSDPTargetCanvas
+	Targets []string
+}
+
+// DiscoverSSDP searches for controllers via SSDP until ctx is done (or DefaultDiscoveryTimeout passed)
+func DiscoverSSDP(ctx context.Context) ([]DiscoveredController, error) {
+	return (&SSDPDiscoverer{}).Discover(ctx)
+}
+
+// DiscoveryError reports which discovery methods of DiscoverAll failed
+type DiscoveryError struct {
+	MDNS error
+	SSDP error
+}
+
+// Error implements error
+func (e *DiscoveryError) Error() string {
+	var failed []string
+
+	if e.MDNS != nil {
+		failed = append(failed, "mDNS discovery failed: "+e.MDNS.Error())
+	}
+
+	if e.SSDP != nil {
+		failed = append(failed, "SSDP discovery failed: "+e.SSDP.Error())
+	}
+
+	return strings.Join(failed, ", ")
+}
+
+// DiscoverAll runs mDNS and SSDP discovery at the same time and merges their results.
+// Controllers found by both are reported once, preferring the mDNS result.
+// If a method fails the controllers found by the other one are returned together with a *DiscoveryError
+func DiscoverAll(ctx context.Context) ([]DiscoveredController, error) {
+	var wg sync.WaitGroup
+	var mdns, ssdp []DiscoveredController
+	var mdnsErr, ssdpErr error
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		mdns, mdnsErr = Discover(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		ssdp, ssdpErr = DiscoverSSDP(ctx)
+	}()
+	wg.Wait()
+
+	controllers := mergeDiscovered(mdns, ssdp)
+
+	if mdnsErr != nil || ssdpErr != nil {
+		return controllers, &DiscoveryError{MDNS: mdnsErr, SSDP: ssdpErr}
+	}
+
+	return controllers, nil
+}
+
+// mergeDiscovered merges the results of mDNS and SSDP discovery, controllers found by both are reported once
+func mergeDiscovered(mdns, ssdp []DiscoveredController) []DiscoveredController {
+	controllers := []DiscoveredController{}
+	seen := map[string]bool{}
+
+	for _, c := range append(mdns, ssdp...) {
+		key := c.DeviceID
+		if key == "" {
+			key = net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+		}
+
+		if seen[key] || seen[c.Host] {
+			continue
+		}
+
+		seen[key], seen[c.Host] = true, true
+		controllers = append(controllers, c)
+	}
+
+	return controllers
+}
+
+// Discover sends an M-SEARCH for every target and collects every answer until ctx is done
+// (or DefaultDiscoveryTimeout passed if ctx has no deadline)
+func (s *SSDPDiscoverer) Discover(ctx context.Context) ([]DiscoveredController, error) {
+	address, targets := s.Address, s.Targets
+	if address == "" {
+		address = SSDPAddress
+	}
+
+	if len(targets) == 0 {
+		targets = []string{SSDPTargetAurora, SSDPTargetCanvas}
+	}
+
+	destination, err := net.ResolveUDPAddr("udp4", address)
+	if err != nil {
+		return nil, err
+	}
+
+	con, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer con.Close()
+
+	for _, target := range targets {
+		search := fmt.Sprintf("M-SEARCH * HTTP/1.1\r\nHOST: %s\r\nMAN: \"ssdp:discover\"\r\nMX: 2\r\nST: %s\r\n\r\n", SSDPAddress, target)
+
+		if _, err := con.WriteToUDP([]byte(search), destination); err != nil {
+			return nil, err
+		}
+	}
+
+	controllers := []DiscoveredController{}
+	seen := map[string]bool{}
+
+	err = readUntil(ctx, con, func(packet []byte, from *net.UDPAddr) {
+		c, ok := parseSSDPResponse(packet, from)
+
+		if !ok || seen[c.URL()] {
+			return
+		}
+
+		seen[c.URL()] = true
+		controllers = append(controllers, c)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return controllers, nil
+}
+
+// parseSSDPResponse parses an M-SEARCH response of a nanoleaf controller
+func parseSSDPResponse(packet []byte, from *net.UDPAddr) (DiscoveredController, bool) {
+	var c DiscoveredController
+
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(packet)), nil)
+	if err != nil {
+		return c, false
+	}
+	resp.Body.Close()
+
+	target := strings.ToLower(resp.Header.Get("ST"))
+	model, ok := ssdpModels[target]
+	if resp.StatusCode != http.StatusOK || !ok {
+		return c, false
+	}
+
+	c = DiscoveredController{
+		Name:     resp.Header.Get("nl-devicename"),
+		Host:     from.IP.String(),
+		Port:     DefaultAPIPort,
+		Model:    model,
+		DeviceID: resp.Header.Get("nl-deviceid"),
+		Source:   DiscoverySourceSSDP,
+	}
+
+	if location, err := neturl.Parse(resp.Header.Get("Location")); err == nil && location.Hostname() != "" {
+		c.Host = location.Hostname()
+
+		if port, err := strconv.Atoi(location.Port()); err == nil {
+			c.Port = port
+		}
+	}
+
+	return c, true
+}
@@ -0,0 +1,133 @@
+package nanoleaf
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+)
+
+// ssdpResponse joins header lines into an M-SEARCH response
+func ssdpResponse(lines ...string) []byte {
+	return []byte(strings.Join(lines, "\r\n") + "\r\n\r\n")
+}
+
+func TestParseSSDPResponse(t *testing.T) {
+	from := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 30), Port: 1900}
+
+	tests := []struct {
+		name   string
+		packet []byte
+		ok     bool
+		want   DiscoveredController
+	}{
+		{
+			name: "light panels",
+			packet: ssdpResponse(
+				"HTTP/1.1 200 OK",
+				"Cache-Control: max-age=60",
+				"ST: nanoleaf_aurora:light",
+				"USN: uuid:2b3a1c8e-1e4f-4c0d-9a3b-50e4d5a1b2c3",
+				"Location: http://192.168.1.15:16021",
+				"nl-devicename: Light Panels 50:e4:d5",
+				"nl-deviceid: 50:E4:D5:A1:B2:C3",
+			),
+			ok: true,
+			want: DiscoveredController{
+				Name:     "Light Panels 50:e4:d5",
+				Host:     "192.168.1.15",
+				Port:     DefaultAPIPort,
+				Model:    "NL22",
+				DeviceID: "50:E4:D5:A1:B2:C3",
+				Source:   DiscoverySourceSSDP,
+			},
+		},
+		{
+			name: "canvas",
+			packet: ssdpResponse(
+				"HTTP/1.1 200 OK",
+				"Cache-Control: max-age=60",
+				"ST: nanoleaf:nl29",
+				"USN: uuid:8f0d5c1a-7b2e-4e9a-a1d4-7e3a00112233",
+				"Location: http://192.168.1.20:16021",
+				"nl-devicename: Canvas 7E3A",
+				"nl-deviceid: AA:BB:CC:DD:EE:FF",
+			),
+			ok: true,
+			want: DiscoveredController{
+				Name:     "Canvas 7E3A",
+				Host:     "192.168.1.20",
+				Port:     DefaultAPIPort,
+				Model:    "NL29",
+				DeviceID: "AA:BB:CC:DD:EE:FF",
+				Source:   DiscoverySourceSSDP,
+			},
+		},
+		{
+			name:   "without location",
+			packet: ssdpResponse("HTTP/1.1 200 OK", "ST: NANOLEAF:NL29"),
+			ok:     true,
+			want: DiscoveredController{
+				Host:   "192.168.1.30",
+				Port:   DefaultAPIPort,
+				Model:  "NL29",
+				Source: DiscoverySourceSSDP,
+			},
+		},
+		{
+			name:   "other device",
+			packet: ssdpResponse("HTTP/1.1 200 OK", "ST: urn:schemas-upnp-org:device:MediaRenderer:1", "Location: http://192.168.1.40:8080/desc.xml"),
+		},
+		{
+			name:   "search request",
+			packet: ssdpResponse("M-SEARCH * HTTP/1.1", "HOST: 239.255.255.250:1900", "ST: nanoleaf:nl29"),
+		},
+		{
+			name:   "garbage",
+			packet: []byte("\x00\x01\x02"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseSSDPResponse(tt.packet, from)
+
+			if ok != tt.ok {
+				t.Fatalf("got ok %v, want %v", ok, tt.ok)
+			}
+
+			if ok && got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeDiscovered(t *testing.T) {
+	mdns := []DiscoveredController{
+		{Name: "Canvas 7E3A", Host: "192.168.1.20", Port: DefaultAPIPort, DeviceID: "AA:BB:CC:DD:EE:FF", Source: DiscoverySourceMDNS},
+	}
+	ssdp := []DiscoveredController{
+		{Name: "Canvas 7E3A", Host: "192.168.1.20", Port: DefaultAPIPort, DeviceID: "AA:BB:CC:DD:EE:FF", Source: DiscoverySourceSSDP},
+		{Name: "Light Panels 50:e4:d5", Host: "192.168.1.15", Port: DefaultAPIPort, Source: DiscoverySourceSSDP},
+	}
+
+	merged := mergeDiscovered(mdns, ssdp)
+
+	if len(merged) != 2 || merged[0].Source != DiscoverySourceMDNS || merged[1].Host != "192.168.1.15" {
+		t.Errorf("got %+v", merged)
+	}
+}
+
+func TestDiscoveryError(t *testing.T) {
+	err := &DiscoveryError{MDNS: errors.New("no multicast route")}
+
+	if got, want := err.Error(), "mDNS discovery failed: no multicast route"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	err.SSDP = errors.New("timeout")
+	if got := err.Error(); !strings.Contains(got, "mDNS") || !strings.Contains(got, "SSDP discovery failed: timeout") {
+		t.Errorf("got %q", got)
+	}
+}
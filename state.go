@@ -114,7 +114,7 @@ func (s *NanoState) GetBrightness() (Brightness, error) {
 	return brightness, nil
 }
 
-// SetBrightness sets the Light Panels Brightness over given time (ms)
+// SetBrightness sets the Light Panels Brightness over given time (seconds)
 func (s *NanoState) SetBrightness(value, time int) error {
 	body := jsonPayload{
 		"brightness": jsonPayload{
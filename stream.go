@@ -5,17 +5,39 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net"
 	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"sync"
 )
 
+// extControl versions
+const (
+	StreamVersionV1 = "v1"
+	StreamVersionV2 = "v2"
+)
+
+// StreamPortV2 is the fixed udp port used by extControl v2
+const StreamPortV2 = 60222
+
+// modelLightPanels is the model number of the Light Panels (Aurora)
+const modelLightPanels = "NL22"
+
+// lightPanelsV2Firmware is the first firmware of the Light Panels supporting extControl v2
+const lightPanelsV2Firmware = "3.1.0"
+
 // NanoStream udp connection to nanoleaf
 type NanoStream struct {
+	mu        sync.Mutex
 	nano      *Nanoleaf
 	con       net.Conn
 	connected bool
 	address   string
 	port      int
+	version   string
 }
 
 // FrameEffect describes a frame for a panel
@@ -51,33 +73,62 @@ func (s *NanoStream) WriteEffect(effect StreamEffect) error {
 		return nil
 	}
 
-	buf := new(bytes.Buffer)
-	binary.Write(buf, binary.LittleEndian, uint8(len(effect.Panels)))
-
-	for _, panel := range effect.Panels {
-		nFrames := uint8(len(panel.Frames))
-		binary.Write(buf, binary.LittleEndian, uint8(panel.ID))
-		binary.Write(buf, binary.LittleEndian, nFrames)
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-		for _, frame := range panel.Frames {
-			binary.Write(buf, binary.LittleEndian, uint8(frame.Red))
-			binary.Write(buf, binary.LittleEndian, uint8(frame.Green))
-			binary.Write(buf, binary.LittleEndian, uint8(frame.Blue))
-			binary.Write(buf, binary.LittleEndian, uint8(0))
-			binary.Write(buf, binary.LittleEndian, uint8(frame.Transition))
-		}
+	if !s.connected {
+		return ErrStreamNotConnected
 	}
 
-	if _, err := s.con.Write(buf.Bytes()); err != nil {
+	packet, err := encodeStreamEffect(s.version, effect)
+
+	if err != nil {
 		return err
 	}
 
-	return nil
+	_, err = s.con.Write(packet)
+
+	if o := s.nano.getObserver(); o != nil {
+		o.ObservePacket(len(packet), err)
+	}
+
+	return err
+}
+
+// Version returns the extControl version negotiated by Activate
+func (s *NanoStream) Version() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.version
+}
+
+// DetectVersion returns the extControl version supported by the controller.
+// Light Panels before firmware 3.1.0 stay on v1, every other controller uses v2
+func (s *NanoStream) DetectVersion() (string, error) {
+	info, err := s.nano.GetControllerInfo()
+
+	if err != nil {
+		return "", err
+	}
+
+	return streamVersionFor(info.Model, info.FirmwareVersion), nil
 }
 
-// Activate activates extControl to allow creating a udp connection
+// Activate activates extControl to allow creating a udp connection.
+// An empty version selects the version supported by the controller
 func (s *NanoStream) Activate(version string) error {
-	if version != "v1" {
+	if version == "" {
+		detected, err := s.DetectVersion()
+
+		if err != nil {
+			return err
+		}
+
+		version = detected
+	}
+
+	if version != StreamVersionV1 && version != StreamVersionV2 {
 		return ErrInvalidVersion
 	}
 
@@ -100,6 +151,26 @@ func (s *NanoStream) Activate(version string) error {
 		return ErrUnauthorized
 	}
 
+	if version == StreamVersionV2 {
+		if resp.StatusCode() != http.StatusOK && resp.StatusCode() != http.StatusNoContent {
+			return ErrUnexpectedResponse
+		}
+
+		host, err := neturl.Parse(s.nano.url)
+
+		if err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		s.address = host.Hostname()
+		s.port = StreamPortV2
+		s.version = version
+		s.mu.Unlock()
+
+		return nil
+	}
+
 	if resp.StatusCode() != http.StatusOK {
 		return ErrUnexpectedResponse
 	}
@@ -113,20 +184,30 @@ func (s *NanoStream) Activate(version string) error {
 		return ErrParsingJSON
 	}
 
+	s.mu.Lock()
 	s.address = jsonResponse.Address
 	s.port = jsonResponse.Port
+	s.version = version
+	s.mu.Unlock()
 
 	return nil
 }
 
 // Connect connects to nanoleaf via udp
 func (s *NanoStream) Connect() error {
-	con, err := net.Dial("udp", fmt.Sprintf("%s:%d", s.address, s.port))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	con, err := net.Dial("udp", net.JoinHostPort(s.address, strconv.Itoa(s.port)))
 
 	if err != nil {
 		return err
 	}
 
+	if s.connected {
+		s.con.Close()
+	}
+
 	s.con = con
 	s.connected = true
 	return nil
@@ -134,6 +215,13 @@ func (s *NanoStream) Connect() error {
 
 // Disconnect closes udp connection
 func (s *NanoStream) Disconnect() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.connected {
+		return nil
+	}
+
 	err := s.con.Close()
 
 	if err != nil {
@@ -146,5 +234,109 @@ func (s *NanoStream) Disconnect() error {
 
 // IsConnected checks if there is a connection
 func (s *NanoStream) IsConnected() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	return s.connected
 }
+
+// encodeStreamEffect encodes effect in the wire format of the given extControl version.
+// v2 carries a single frame per panel, so only the first frame of each panel is sent
+func encodeStreamEffect(version string, effect StreamEffect) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	if version == StreamVersionV2 {
+		if len(effect.Panels) > math.MaxUint16 {
+			return nil, ErrStreamEffectTooLarge
+		}
+
+		binary.Write(buf, binary.BigEndian, uint16(len(effect.Panels)))
+
+		for _, panel := range effect.Panels {
+			var frame FrameEffect
+
+			if len(panel.Frames) > 0 {
+				frame = panel.Frames[0]
+			}
+
+			if panel.ID < 0 || panel.ID > math.MaxUint16 {
+				return nil, ErrPanelIDOutOfRange
+			}
+
+			binary.Write(buf, binary.BigEndian, uint16(panel.ID))
+			binary.Write(buf, binary.BigEndian, uint8(frame.Red))
+			binary.Write(buf, binary.BigEndian, uint8(frame.Green))
+			binary.Write(buf, binary.BigEndian, uint8(frame.Blue))
+			binary.Write(buf, binary.BigEndian, uint8(0))
+			binary.Write(buf, binary.BigEndian, uint16(frame.Transition))
+		}
+
+		return buf.Bytes(), nil
+	}
+
+	if len(effect.Panels) > math.MaxUint8 {
+		return nil, ErrStreamEffectTooLarge
+	}
+
+	binary.Write(buf, binary.LittleEndian, uint8(len(effect.Panels)))
+
+	for _, panel := range effect.Panels {
+		if panel.ID < 0 || panel.ID > math.MaxUint8 {
+			return nil, ErrPanelIDOutOfRange
+		}
+
+		if len(panel.Frames) > math.MaxUint8 {
+			return nil, ErrStreamEffectTooLarge
+		}
+
+		nFrames := uint8(len(panel.Frames))
+		binary.Write(buf, binary.LittleEndian, uint8(panel.ID))
+		binary.Write(buf, binary.LittleEndian, nFrames)
+
+		for _, frame := range panel.Frames {
+			binary.Write(buf, binary.LittleEndian, uint8(frame.Red))
+			binary.Write(buf, binary.LittleEndian, uint8(frame.Green))
+			binary.Write(buf, binary.LittleEndian, uint8(frame.Blue))
+			binary.Write(buf, binary.LittleEndian, uint8(0))
+			binary.Write(buf, binary.LittleEndian, uint8(frame.Transition))
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// streamVersionFor returns the extControl version for given model and firmware
+func streamVersionFor(model, firmware string) string {
+	if model == modelLightPanels && compareVersions(firmware, lightPanelsV2Firmware) < 0 {
+		return StreamVersionV1
+	}
+
+	return StreamVersionV2
+}
+
+// compareVersions compares dotted version numbers like 3.1.0, missing or non numeric parts count as 0
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+
+		if x != y {
+			if x < y {
+				return -1
+			}
+
+			return 1
+		}
+	}
+
+	return 0
+}
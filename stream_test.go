@@ -0,0 +1,132 @@
+package nanoleaf
+
+import (
+	"bytes"
+	"testing"
+)
+
+// effectOf returns an effect of n panels with the given number of frames each
+func effectOf(panels, frames int) StreamEffect {
+	effect := StreamEffect{Panels: make([]PanelEffect, panels)}
+
+	for i := range effect.Panels {
+		effect.Panels[i] = PanelEffect{ID: i % 256, Frames: make([]FrameEffect, frames)}
+	}
+
+	return effect
+}
+
+func TestEncodeStreamEffectV1(t *testing.T) {
+	effect := StreamEffect{Panels: []PanelEffect{
+		{ID: 107, Frames: []FrameEffect{{Red: 255, Green: 0, Blue: 128, Transition: 1}, {Red: 1, Green: 2, Blue: 3, Transition: 20}}},
+		{ID: 255, Frames: []FrameEffect{{Red: 10, Green: 20, Blue: 30, Transition: 5}}},
+	}}
+
+	got, err := encodeStreamEffect(StreamVersionV1, effect)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{
+		2,
+		107, 2, 255, 0, 128, 0, 1, 1, 2, 3, 0, 20,
+		255, 1, 10, 20, 30, 0, 5,
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("got % x, want % x", got, want)
+	}
+}
+
+func TestEncodeStreamEffectV2(t *testing.T) {
+	effect := StreamEffect{Panels: []PanelEffect{
+		{ID: 20815, Frames: []FrameEffect{{Red: 255, Green: 0, Blue: 128, Transition: 300}, {Red: 1, Green: 2, Blue: 3}}},
+		{ID: 5},
+	}}
+
+	got, err := encodeStreamEffect(StreamVersionV2, effect)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only the first frame is sent, panels without frames are sent black
+	want := []byte{
+		0x00, 0x02,
+		0x51, 0x4f, 255, 0, 128, 0, 0x01, 0x2c,
+		0x00, 0x05, 0, 0, 0, 0, 0x00, 0x00,
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("got % x, want % x", got, want)
+	}
+}
+
+func TestEncodeStreamEffectLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		effect  StreamEffect
+		want    error
+	}{
+		{name: "v1 panel id", version: StreamVersionV1, effect: StreamEffect{Panels: []PanelEffect{{ID: 256}}}, want: ErrPanelIDOutOfRange},
+		{name: "v1 negative panel id", version: StreamVersionV1, effect: StreamEffect{Panels: []PanelEffect{{ID: -1}}}, want: ErrPanelIDOutOfRange},
+		{name: "v1 255 panels", version: StreamVersionV1, effect: effectOf(255, 1)},
+		{name: "v1 256 panels", version: StreamVersionV1, effect: effectOf(256, 1), want: ErrStreamEffectTooLarge},
+		{name: "v1 255 frames", version: StreamVersionV1, effect: effectOf(1, 255)},
+		{name: "v1 256 frames", version: StreamVersionV1, effect: effectOf(1, 256), want: ErrStreamEffectTooLarge},
+		{name: "v2 panel id", version: StreamVersionV2, effect: StreamEffect{Panels: []PanelEffect{{ID: 65535}}}},
+		{name: "v2 panel id out of range", version: StreamVersionV2, effect: StreamEffect{Panels: []PanelEffect{{ID: 65536}}}, want: ErrPanelIDOutOfRange},
+		{name: "v2 256 panels", version: StreamVersionV2, effect: effectOf(256, 1)},
+		{name: "v2 too many panels", version: StreamVersionV2, effect: effectOf(65536, 0), want: ErrStreamEffectTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := encodeStreamEffect(tt.version, tt.effect); err != tt.want {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamVersionFor(t *testing.T) {
+	tests := []struct {
+		model    string
+		firmware string
+		want     string
+	}{
+		{model: "NL22", firmware: "1.5.0", want: StreamVersionV1},
+		{model: "NL22", firmware: "3.0.9", want: StreamVersionV1},
+		{model: "NL22", firmware: "3.1.0", want: StreamVersionV2},
+		{model: "NL22", firmware: "3.1", want: StreamVersionV2},
+		{model: "NL22", firmware: "10.0.0", want: StreamVersionV2},
+		{model: "NL22", firmware: "", want: StreamVersionV1},
+		{model: "NL29", firmware: "1.1.0", want: StreamVersionV2},
+		{model: "NL42", firmware: "7.1.1", want: StreamVersionV2},
+	}
+
+	for _, tt := range tests {
+		if got := streamVersionFor(tt.model, tt.firmware); got != tt.want {
+			t.Errorf("streamVersionFor(%q, %q) = %q, want %q", tt.model, tt.firmware, got, tt.want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "3.1.0", b: "3.1.0", want: 0},
+		{a: "3.1", b: "3.1.0", want: 0},
+		{a: "3.0.10", b: "3.1.0", want: -1},
+		{a: "3.10.0", b: "3.9.9", want: 1},
+		{a: "4", b: "3.1.0", want: 1},
+	}
+
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package nanoleaf
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/cmplx"
+	"sort"
+	"sync"
+	"time"
+)
+
+// VisualizerMode decides how audio is mapped onto the panels
+type VisualizerMode int
+
+// Visualizer modes
+const (
+	// VisualizerSpectrum assigns frequency bands to panels from left to right
+	VisualizerSpectrum VisualizerMode = iota
+	// VisualizerPulse lights all panels with the overall energy
+	VisualizerPulse
+	// VisualizerBeatFlash flashes all panels on every detected beat
+	VisualizerBeatFlash
+)
+
+// visualizer tuning
+const (
+	visualizerWindow     = 1024
+	visualizerMinFreq    = 40.0
+	visualizerMaxFreq    = 16000.0
+	beatHistory          = 43
+	beatThreshold        = 1.5
+	beatMinEnergy        = 1e-4
+	beatCooldown         = 150 * time.Millisecond
+	visualizerPeakDecay  = 0.995
+	visualizerFlashDecay = 0.85
+)
+
+// AudioAnalysis result of analyzing one window of samples
+type AudioAnalysis struct {
+	Bands  []float64
+	Energy float64
+	Beat   bool
+}
+
+// Visualizer drives panel colors from pcm audio
+type Visualizer struct {
+	mu        sync.Mutex
+	stream    *NanoStream
+	fb        *Framebuffer
+	order     []int
+	mode      VisualizerMode
+	fps       int
+	realtime  bool
+	bands     int
+	peaks     []float64
+	energyMax float64
+	history   []float64
+	lastBeat  time.Duration
+	position  time.Duration
+	flash     float64
+	hue       float64
+}
+
+// NewVisualizer returns a visualizer for the given layout and global orientation writing to stream
+func NewVisualizer(stream *NanoStream, layout *PanelLayout, orientation int) *Visualizer {
+	positions := layout.OrientedPositions(orientation)
+	fb := NewFramebuffer(layout)
+	order := fb.Panels()
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return positions[order[i]].X < positions[order[j]].X
+	})
+
+	bands := len(order)
+	if bands == 0 {
+		bands = 1
+	}
+
+	return &Visualizer{
+		stream:    stream,
+		fb:        fb,
+		order:     order,
+		mode:      VisualizerSpectrum,
+		fps:       DefaultRendererFPS,
+		realtime:  true,
+		bands:     bands,
+		peaks:     make([]float64, bands),
+		lastBeat:  -beatCooldown,
+		energyMax: beatMinEnergy,
+	}
+}
+
+// SetMode selects how audio is mapped onto the panels
+func (v *Visualizer) SetMode(mode VisualizerMode) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.mode = mode
+}
+
+// SetFPS sets how many updates per second are sent. Values <= 0 are ignored
+func (v *Visualizer) SetFPS(fps int) {
+	if fps <= 0 {
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.fps = fps
+}
+
+// SetRealtime decides if Run paces itself to the audio (files) or reads as fast as the reader delivers (pipes)
+func (v *Visualizer) SetRealtime(realtime bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.realtime = realtime
+}
+
+// Run reads pcm until EOF or ctx is cancelled and streams the visualization
+func (v *Visualizer) Run(ctx context.Context, pcm *PCMReader) error {
+	v.mu.Lock()
+	rate := pcm.Format().SampleRate
+	hop := rate / v.fps
+	realtime := v.realtime
+	v.mu.Unlock()
+
+	if hop <= 0 {
+		hop = 1
+	}
+
+	window := make([]float64, visualizerWindow)
+	chunk := make([]float64, hop)
+	interval := time.Duration(hop) * time.Second / time.Duration(rate)
+	next := time.Now()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		n, err := pcm.Read(chunk)
+		if n > 0 {
+			// keep the last visualizerWindow samples
+			if n >= len(window) {
+				copy(window, chunk[n-len(window):n])
+			} else {
+				copy(window, window[n:])
+				copy(window[len(window)-n:], chunk[:n])
+			}
+
+			v.mu.Lock()
+			v.position += time.Duration(n) * time.Second / time.Duration(rate)
+			v.mu.Unlock()
+
+			analysis := v.Analyze(window, rate)
+			v.draw(analysis)
+
+			if err := v.stream.WriteFramebuffer(v.fb, false); err != nil {
+				return err
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if realtime {
+			next = next.Add(interval)
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Until(next)):
+			}
+		}
+	}
+}
+
+// Analyze computes normalized frequency bands, energy and beat of a window of mono samples. Windows shorter
+// than two samples or without a sample rate can't be analyzed and return silence
+func (v *Visualizer) Analyze(samples []float64, sampleRate int) AudioAnalysis {
+	if len(samples) < 2 || sampleRate <= 0 {
+		v.mu.Lock()
+		defer v.mu.Unlock()
+
+		return AudioAnalysis{Bands: make([]float64, v.bands)}
+	}
+
+	size := 1
+	for size < len(samples) {
+		size <<= 1
+	}
+
+	x := make([]complex128, size)
+	energy := 0.0
+	for i, s := range samples {
+		// hann window
+		w := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(len(samples)-1))
+		x[i] = complex(s*w, 0)
+		energy += s * s
+	}
+	energy /= float64(len(samples))
+
+	fft(x)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	maxFreq := math.Min(visualizerMaxFreq, float64(sampleRate)/2)
+	binWidth := float64(sampleRate) / float64(size)
+	bands := make([]float64, v.bands)
+
+	for b := range bands {
+		// log spaced bands
+		low := visualizerMinFreq * math.Pow(maxFreq/visualizerMinFreq, float64(b)/float64(v.bands))
+		high := visualizerMinFreq * math.Pow(maxFreq/visualizerMinFreq, float64(b+1)/float64(v.bands))
+		from := int(low / binWidth)
+		to := int(math.Ceil(high / binWidth))
+
+		if to > size/2 {
+			to = size / 2
+		}
+
+		sum, count := 0.0, 0
+		for i := from; i < to; i++ {
+			sum += cmplx.Abs(x[i])
+			count++
+		}
+
+		if count > 0 {
+			sum /= float64(count)
+		}
+
+		v.peaks[b] = math.Max(v.peaks[b]*visualizerPeakDecay, sum)
+		if v.peaks[b] > 0 {
+			bands[b] = sum / v.peaks[b]
+		}
+	}
+
+	beat := false
+	if len(v.history) == beatHistory {
+		avg := 0.0
+		for _, e := range v.history {
+			avg += e
+		}
+		avg /= float64(len(v.history))
+
+		if energy > beatMinEnergy && energy > avg*beatThreshold && v.position-v.lastBeat >= beatCooldown {
+			beat = true
+			v.lastBeat = v.position
+		}
+
+		v.history = v.history[1:]
+	}
+	v.history = append(v.history, energy)
+
+	v.energyMax = math.Max(v.energyMax*visualizerPeakDecay, energy)
+
+	return AudioAnalysis{
+		Bands:  bands,
+		Energy: energy / v.energyMax,
+		Beat:   beat,
+	}
+}
+
+// draw maps an analysis onto the framebuffer according to the current mode
+func (v *Visualizer) draw(a AudioAnalysis) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	switch v.mode {
+	case VisualizerSpectrum:
+		for i, id := range v.order {
+			band := i * len(a.Bands) / len(v.order)
+			hue := 270 * float64(band) / math.Max(1, float64(len(a.Bands)-1))
+			v.fb.Set(id, hsbToRGB(hue, 100, 100*a.Bands[band]))
+		}
+	case VisualizerPulse:
+		v.hue = math.Mod(v.hue+0.5, 360)
+		v.fb.Fill(hsbToRGB(v.hue, 100, 100*a.Energy))
+	case VisualizerBeatFlash:
+		if a.Beat {
+			v.flash = 1
+		} else {
+			v.flash *= visualizerFlashDecay
+		}
+
+		v.fb.Fill(hsbToRGB(0, 0, 100*v.flash))
+	}
+}
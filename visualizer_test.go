@@ -0,0 +1,63 @@
+package nanoleaf
+
+import (
+	"math"
+	"testing"
+)
+
+// sine returns n samples of a sine wave with the given frequency
+func sine(n, sampleRate int, freq float64) []float64 {
+	samples := make([]float64, n)
+
+	for i := range samples {
+		samples[i] = math.Sin(2 * math.Pi * freq * float64(i) / float64(sampleRate))
+	}
+
+	return samples
+}
+
+func TestAnalyzeShortWindows(t *testing.T) {
+	v := NewVisualizer(nil, row(0, 1, 2, 3, 4), 0)
+
+	for _, tt := range []struct {
+		samples    []float64
+		sampleRate int
+	}{
+		{samples: nil, sampleRate: 44100},
+		{samples: []float64{0.5}, sampleRate: 44100},
+		{samples: []float64{0.5, -0.5}, sampleRate: 0},
+	} {
+		a := v.Analyze(tt.samples, tt.sampleRate)
+
+		if len(a.Bands) != 4 || a.Energy != 0 || a.Beat {
+			t.Errorf("%v at %d Hz: got %+v, want silence", tt.samples, tt.sampleRate, a)
+		}
+
+		for _, b := range a.Bands {
+			if b != 0 {
+				t.Errorf("%v at %d Hz: got %+v, want silence", tt.samples, tt.sampleRate, a)
+				break
+			}
+		}
+	}
+}
+
+func TestAnalyzeSine(t *testing.T) {
+	v := NewVisualizer(nil, row(0, 1, 2, 3, 4), 0)
+
+	a := v.Analyze(sine(visualizerWindow, 44100, 60), 44100)
+
+	for i, b := range a.Bands {
+		if math.IsNaN(b) || b < 0 || b > 1 {
+			t.Errorf("band %d: got %v, want a value between 0 and 1", i, b)
+		}
+	}
+
+	if a.Bands[0] != 1 {
+		t.Errorf("got bands %v, want the lowest band at its peak", a.Bands)
+	}
+
+	if math.Abs(a.Energy-1) > 1e-9 {
+		t.Errorf("got energy %v, want 1", a.Energy)
+	}
+}
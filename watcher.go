@@ -0,0 +1,154 @@
+package nanoleaf
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// extControlEffect is reported as the selected effect while extControl is active
+const extControlEffect = "*ExtControl*"
+
+// DefaultWatchInterval is used if a watcher is created without a valid interval
+const DefaultWatchInterval = 5 * time.Second
+
+// StreamState describes the state of an extControl stream
+type StreamState int
+
+// Stream states reported by a StreamWatcher
+const (
+	// StreamStateActive extControl is running and packets reach the panels
+	StreamStateActive StreamState = iota
+	// StreamStateLost extControl ended, e.g. the controller rebooted or another effect was selected
+	StreamStateLost
+	// StreamStateReconnecting extControl is being activated again
+	StreamStateReconnecting
+)
+
+// String returns a readable name of the state
+func (s StreamState) String() string {
+	switch s {
+	case StreamStateActive:
+		return "active"
+	case StreamStateLost:
+		return "lost"
+	case StreamStateReconnecting:
+		return "reconnecting"
+	}
+
+	return "unknown"
+}
+
+// StreamWatcher periodically checks if extControl is still active and re-activates it if not
+type StreamWatcher struct {
+	mu       sync.Mutex
+	stream   *NanoStream
+	version  string
+	interval time.Duration
+	state    StreamState
+	onChange func(state StreamState, err error)
+}
+
+// NewStreamWatcher returns a watcher for stream. version is passed to Activate on reconnects,
+// onChange (may be nil) is called on every state transition with the error that caused it
+func NewStreamWatcher(stream *NanoStream, version string, interval time.Duration, onChange func(state StreamState, err error)) *StreamWatcher {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	return &StreamWatcher{
+		stream:   stream,
+		version:  version,
+		interval: interval,
+		state:    StreamStateActive,
+		onChange: onChange,
+	}
+}
+
+// Reconnect activates extControl again and replaces the udp connection
+func (s *NanoStream) Reconnect(version string) error {
+	if err := s.Activate(version); err != nil {
+		return err
+	}
+
+	if err := s.Disconnect(); err != nil {
+		return err
+	}
+
+	return s.Connect()
+}
+
+// State returns the last known state
+func (w *StreamWatcher) State() StreamState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.state
+}
+
+// Run checks the stream every interval until ctx is cancelled
+func (w *StreamWatcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			w.Check()
+		}
+	}
+}
+
+// Check verifies once that extControl is active and reconnects if it is not
+func (w *StreamWatcher) Check() {
+	err := w.verify()
+
+	if err == nil {
+		w.transition(StreamStateActive, nil)
+		return
+	}
+
+	w.transition(StreamStateLost, err)
+
+	w.transition(StreamStateReconnecting, nil)
+
+	if err := w.stream.Reconnect(w.version); err != nil {
+		w.transition(StreamStateLost, err)
+		return
+	}
+
+	w.transition(StreamStateActive, nil)
+}
+
+// verify returns ErrStreamInactive if another effect is selected or the request error if the controller is unreachable
+func (w *StreamWatcher) verify() error {
+	if w.stream.nano.Effects == nil {
+		return ErrUnauthorized
+	}
+
+	effect, err := w.stream.nano.Effects.Get()
+
+	if err != nil {
+		return err
+	}
+
+	if effect != extControlEffect {
+		return ErrStreamInactive
+	}
+
+	return nil
+}
+
+// transition updates the state and reports changes
+func (w *StreamWatcher) transition(state StreamState, err error) {
+	w.mu.Lock()
+	changed := w.state != state
+	w.state = state
+	w.mu.Unlock()
+
+	if w.onChange != nil && (changed || err != nil) {
+		w.onChange(state, err)
+	}
+}